@@ -1,3 +1,8 @@
-data "jks_keystore" "example" {
+resource "jks_keystore" "example" {
+  password = "password"
+}
 
+data "jks_keystore" "example" {
+  keystore = jks_keystore.example.file
+  password = "password"
 }
@@ -0,0 +1,6 @@
+ephemeral "jks_keystore" "example" {
+  password = "password"
+  subject = {
+    common_name = "example.com"
+  }
+}
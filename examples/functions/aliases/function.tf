@@ -0,0 +1,3 @@
+output "aliases" {
+  value = provider::jks::aliases(jks_keystore.example.file, "password")
+}
@@ -0,0 +1,3 @@
+output "certificate" {
+  value = provider::jks::certificate_pem(jks_keystore.example.file, "password", "keystore")
+}
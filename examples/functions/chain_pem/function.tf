@@ -0,0 +1,10 @@
+resource "kubernetes_secret" "example" {
+  metadata {
+    name = "example-tls"
+  }
+  type = "kubernetes.io/tls"
+  data = {
+    "tls.crt" = provider::jks::chain_pem(jks_keystore.example.file, "password", "keystore")
+    "tls.key" = provider::jks::private_key_pem(jks_keystore.example.file, "password", "keystore")
+  }
+}
@@ -0,0 +1,7 @@
+locals {
+  keystore = provider::jks::decode_keystore(jks_keystore.example.file, "password")
+}
+
+output "expires" {
+  value = local.keystore.entries[0].not_after
+}
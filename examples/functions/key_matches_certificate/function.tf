@@ -0,0 +1,6 @@
+check "signed_certificate" {
+  assert {
+    condition     = provider::jks::key_matches_certificate(jks_keystore.example.file, "password", "keystore", var.signed_certificate_pem).valid
+    error_message = provider::jks::key_matches_certificate(jks_keystore.example.file, "password", "keystore", var.signed_certificate_pem).message
+  }
+}
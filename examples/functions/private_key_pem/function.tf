@@ -0,0 +1,4 @@
+output "private_key" {
+  value     = provider::jks::private_key_pem(jks_keystore.example.file, "password", "keystore")
+  sensitive = true
+}
@@ -0,0 +1,12 @@
+locals {
+  chain = provider::jks::verify_chain(jks_keystore.example.file, "password", var.truststore, var.truststore_password, plantimestamp())
+}
+
+resource "terraform_data" "deploy" {
+  lifecycle {
+    precondition {
+      condition     = local.chain.valid
+      error_message = local.chain.message
+    }
+  }
+}
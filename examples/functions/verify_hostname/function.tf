@@ -0,0 +1,6 @@
+check "hostname" {
+  assert {
+    condition     = provider::jks::verify_hostname(jks_locally_signed_cert.example.cert_pem, "api.example.com").valid
+    error_message = provider::jks::verify_hostname(jks_locally_signed_cert.example.cert_pem, "api.example.com").message
+  }
+}
@@ -0,0 +1,19 @@
+resource "jks_keystore" "example" {
+  password = "password"
+  subject = {
+    common_name = "service.example.com"
+  }
+}
+
+resource "jks_keystore_conversion" "jks" {
+  source_keystore   = jks_keystore.example.file
+  source_password   = jks_keystore.example.password
+  target_store_type = "JKS"
+  target_password   = "another-password"
+}
+
+resource "jks_keystore_conversion" "pem" {
+  source_keystore   = jks_keystore.example.file
+  source_password   = jks_keystore.example.password
+  target_store_type = "PEM"
+}
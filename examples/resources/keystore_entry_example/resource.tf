@@ -0,0 +1,16 @@
+# Adds the internal root to the cacerts of a JDK, keeping the public CAs.
+resource "jks_keystore_entry" "internal_root" {
+  keystore_path   = "/usr/lib/jvm/java-21-openjdk/lib/security/cacerts"
+  password        = "changeit"
+  alias           = "internal root"
+  certificate_pem = file("internal-root.pem")
+}
+
+# Adds the key of a service to a keystore shared with other services.
+resource "jks_keystore_entry" "service" {
+  keystore_path   = "/etc/app/keystore.jks"
+  password        = "password"
+  alias           = "service"
+  certificate_pem = file("service-chain.pem")
+  private_key_pem = file("service-key.pem")
+}
@@ -0,0 +1,15 @@
+resource "jks_keystore" "ca" {
+  password    = "password"
+  common_name = "Example CA"
+}
+
+resource "jks_locally_signed_cert" "example" {
+  ca_keystore      = jks_keystore.ca.file
+  ca_password      = jks_keystore.ca.password
+  cert_request_pem = file("service.csr")
+
+  validity_days      = 90
+  key_usage          = ["digitalSignature", "keyEncipherment"]
+  extended_key_usage = ["serverAuth"]
+  dns_names          = ["service.example.com"]
+}
@@ -0,0 +1,27 @@
+resource "jks_truststore" "example" {
+  certificates = {
+    "internal root" = file("internal-root.pem")
+  }
+}
+
+# Java 17 and earlier need a password to load a truststore.
+resource "jks_truststore" "java17" {
+  certificates = {
+    "internal root" = file("internal-root.pem")
+  }
+  password = "changeit"
+}
+
+# The public CAs of the JDK and of the system, plus the internal root.
+resource "jks_truststore" "merged" {
+  certificates = {
+    "internal root" = file("internal-root.pem")
+  }
+  include_cacerts_path    = "/usr/lib/jvm/java-21-openjdk/lib/security/cacerts"
+  include_pem_bundle_path = "/etc/ssl/certs/ca-certificates.crt"
+}
+
+# The chain handed out by the enterprise CA as a .p7b file.
+resource "jks_truststore" "enterprise" {
+  ca_chain_p7b = filebase64("enterprise-ca.p7b")
+}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &AliasesFunction{}
+
+func NewAliasesFunction() function.Function {
+	return &AliasesFunction{}
+}
+
+// AliasesFunction defines the function implementation.
+type AliasesFunction struct {
+}
+
+func (f *AliasesFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "aliases"
+}
+
+func (f *AliasesFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Lists the aliases of a keystore",
+		Description: "Decodes a base64 encoded PKCS12, JKS or JCEKS keystore, such as the file attribute of a jks_keystore, detecting its type, and returns the aliases of its entries, sorted.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "keystore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS keystore",
+			},
+			function.StringParameter{
+				Name:        "password",
+				Description: "Password for the keystore and its keys",
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (f *AliasesFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var keystore, password string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &keystore, &password))
+
+	if resp.Error != nil {
+		return
+	}
+
+	ks, funcErr := inspectKeystoreArguments(ctx, keystore, "", password)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, ks.Aliases()))
+}
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &ChainPemFunction{}
+
+func NewChainPemFunction() function.Function {
+	return &ChainPemFunction{}
+}
+
+// ChainPemFunction defines the function implementation.
+type ChainPemFunction struct {
+}
+
+func (f *ChainPemFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "chain_pem"
+}
+
+func (f *ChainPemFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Extracts the certificate chain of a keystore entry as PEM",
+		Description: "Decodes a base64 encoded PKCS12, JKS or JCEKS keystore, such as the file attribute of a jks_keystore, detecting its type, and returns the certificate chain of the entry with the given alias in PEM format, leaf first, as expected in the tls.crt of a Kubernetes TLS secret.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "keystore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS keystore",
+			},
+			function.StringParameter{
+				Name:        "password",
+				Description: "Password for the keystore and its keys",
+			},
+			function.StringParameter{
+				Name:        "alias",
+				Description: "Alias of the entry, matched ignoring case",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *ChainPemFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var keystore, password, alias string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &keystore, &password, &alias))
+
+	if resp.Error != nil {
+		return
+	}
+
+	ks, funcErr := inspectKeystoreArguments(ctx, keystore, "", password)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	entry, funcErr := keystoreEntryArgument(ks, alias)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	if len(entry.Certificates) == 0 {
+		resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("entry %q has no certificate", entry.Alias))
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, EncodeCertificatesPEM(entry.Certificates)))
+}
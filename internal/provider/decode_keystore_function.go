@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &DecodeKeystoreFunction{}
+
+func NewDecodeKeystoreFunction() function.Function {
+	return &DecodeKeystoreFunction{}
+}
+
+// DecodeKeystoreFunction defines the function implementation.
+type DecodeKeystoreFunction struct {
+}
+
+// DecodeKeystoreFunctionModel describes the object returned by the function.
+type DecodeKeystoreFunctionModel struct {
+	StoreType types.String         `tfsdk:"store_type"`
+	Aliases   []string             `tfsdk:"aliases"`
+	Entries   []KeystoreEntryModel `tfsdk:"entries"`
+}
+
+var decodeKeystoreAttrTypes = map[string]attr.Type{
+	"store_type": types.StringType,
+	"aliases":    types.ListType{ElemType: types.StringType},
+	"entries":    types.ListType{ElemType: types.ObjectType{AttrTypes: keystoreEntryAttrTypes}},
+}
+
+func (f *DecodeKeystoreFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "decode_keystore"
+}
+
+func (f *DecodeKeystoreFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Lists the entries of a keystore",
+		Description: "Decodes a base64 encoded PKCS12, JKS or JCEKS keystore, such as the file attribute of a jks_keystore, detecting its type, and returns an object with its store_type, " +
+			"sorted aliases and entries. Each entry has the alias, the type, and the subject, issuer, serial_number, not_before, not_after " +
+			"and fingerprint_sha256 of its certificate, the same as the entries of the jks_keystore data source.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "keystore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS keystore",
+			},
+			function.StringParameter{
+				Name:        "password",
+				Description: "Password for the keystore and its keys",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: decodeKeystoreAttrTypes,
+		},
+	}
+}
+
+func (f *DecodeKeystoreFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var keystore, password string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &keystore, &password))
+
+	if resp.Error != nil {
+		return
+	}
+
+	ks, funcErr := inspectKeystoreArguments(ctx, keystore, "", password)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	result, diags := types.ObjectValueFrom(ctx, decodeKeystoreAttrTypes, DecodeKeystoreFunctionModel{
+		StoreType: types.StringValue(ks.StoreType),
+		Aliases:   ks.Aliases(),
+		Entries:   ks.EntryModels(),
+	})
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, result))
+}
+
+// inspectKeystoreArguments decodes a keystore passed as the first function
+// argument with the password passed as the second, and reports failures
+// against the argument at fault.
+func inspectKeystoreArguments(ctx context.Context, keystore, storeType, password string) (*DecodedKeystore, *function.FuncError) {
+	ks, err := InspectKeystore(ctx, keystore, storeType, password)
+	if errors.Is(err, ErrIncorrectPassword) {
+		return nil, function.NewArgumentFuncError(1, err.Error())
+	}
+	if err != nil {
+		return nil, function.NewArgumentFuncError(0, err.Error())
+	}
+	return ks, nil
+}
+
+// keystoreEntryArgument looks up the entry named by the alias passed as the
+// third function argument.
+func keystoreEntryArgument(ks *DecodedKeystore, alias string) (*KeystoreEntry, *function.FuncError) {
+	entry, ok := ks.Entry(alias)
+	if !ok {
+		return nil, function.NewArgumentFuncError(2, fmt.Sprintf("alias %q not found in keystore, which contains %s", alias, strings.Join(ks.Aliases(), ", ")))
+	}
+	return entry, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccDecodeKeystoreFunction(t *testing.T) {
+	keystore := testFixtureBase64(t, "testdata/openssl-modern.p12")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+output "test" {
+  value = provider::jks::decode_keystore(%q, "changeit")
+}
+`, keystore),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"store_type": knownvalue.StringExact(StoreTypePKCS12),
+						"aliases":    knownvalue.ListExact([]knownvalue.Check{knownvalue.StringExact("server")}),
+						"entries": knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.ObjectPartial(map[string]knownvalue.Check{
+								"alias":              knownvalue.StringExact("server"),
+								"subject":            knownvalue.StringExact("CN=leaf.example.com"),
+								"not_after":          knownvalue.StringRegexp(regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`)),
+								"fingerprint_sha256": knownvalue.StringRegexp(regexp.MustCompile(`^[0-9a-f]{64}$`)),
+							}),
+						}),
+					})),
+				},
+			},
+			// The store type is detected.
+			{
+				Config: fmt.Sprintf(`
+output "test" {
+  value = provider::jks::decode_keystore(%q, "password")
+}
+`, testFixtureBase64(t, "testdata/keystore-go.jks")),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"store_type": knownvalue.StringExact(StoreTypeJKS),
+						"aliases":    knownvalue.ListExact([]knownvalue.Check{knownvalue.StringExact("alias")}),
+					})),
+				},
+			},
+		},
+	})
+}
+
+func TestAccDecodeKeystoreFunction_WrongPassword(t *testing.T) {
+	keystore := testFixtureBase64(t, "testdata/openssl-modern.p12")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+output "test" {
+  value = provider::jks::decode_keystore(%q, "wrong-password")
+}
+`, keystore),
+				ExpectError: regexp.MustCompile("keystore password was incorrect"),
+			},
+		},
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &KeyMatchesCertificateFunction{}
+
+func NewKeyMatchesCertificateFunction() function.Function {
+	return &KeyMatchesCertificateFunction{}
+}
+
+// KeyMatchesCertificateFunction defines the function implementation.
+type KeyMatchesCertificateFunction struct {
+}
+
+func (f *KeyMatchesCertificateFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "key_matches_certificate"
+}
+
+func (f *KeyMatchesCertificateFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Checks that a certificate belongs to the private key of a keystore entry",
+		Description: "Compares the public key of the first certificate in a PEM string with the private key of the entry with the given alias in a base64 encoded PKCS12, JKS or JCEKS keystore, " +
+			"for example to check a CA-signed certificate before it is installed as signed_certificate_pem of a jks_keystore. " +
+			"Returns an object whose valid attribute is the result and whose message attribute explains it, for use in the condition and error_message of a precondition or check block.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "keystore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS keystore",
+			},
+			function.StringParameter{
+				Name:        "password",
+				Description: "Password for the keystore and its keys",
+			},
+			function.StringParameter{
+				Name:        "alias",
+				Description: "Alias of the private key entry, matched ignoring case",
+			},
+			function.StringParameter{
+				Name:        "certificate_pem",
+				Description: "PEM encoded certificate",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: verificationAttrTypes,
+		},
+	}
+}
+
+func (f *KeyMatchesCertificateFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var keystore, password, alias, certificatePEM string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &keystore, &password, &alias, &certificatePEM))
+
+	if resp.Error != nil {
+		return
+	}
+
+	ks, funcErr := inspectKeystoreArguments(ctx, keystore, "", password)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	entry, funcErr := keystoreEntryArgument(ks, alias)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	certs, err := ParseCertificatesPEM(certificatePEM)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(3, fmt.Sprintf("error parsing certificate: %s", err))
+		return
+	}
+	if len(certs) == 0 {
+		resp.Error = function.NewArgumentFuncError(3, "no certificate found")
+		return
+	}
+
+	result := NewVerificationModel(KeyMatchesCertificate(entry, certs[0]), fmt.Sprintf("the private key of entry %q matches certificate %s", entry.Alias, certs[0].Subject))
+	value, diags := types.ObjectValueFrom(ctx, verificationAttrTypes, result)
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, value))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccKeyMatchesCertificateFunction(t *testing.T) {
+	keystore := testFixtureBase64(t, "testdata/openssl-modern.p12")
+	entry := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	config := func(certificatePEM string) string {
+		return fmt.Sprintf(`
+output "test" {
+  value = provider::jks::key_matches_certificate(%q, "changeit", "server", %q)
+}
+`, keystore, certificatePEM)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(EncodeCertificatesPEM(entry.Certificates[:1])),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"valid": knownvalue.Bool(true),
+					})),
+				},
+			},
+			{
+				Config: config(EncodeCertificatesPEM(entry.Certificates[1:])),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"valid": knownvalue.Bool(false),
+					})),
+				},
+			},
+		},
+	})
+}
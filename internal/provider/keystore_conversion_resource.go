@@ -0,0 +1,248 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &KeystoreConversionResource{}
+
+func NewKeystoreConversionResource() resource.Resource {
+	return &KeystoreConversionResource{}
+}
+
+// KeystoreConversionResource defines the resource implementation.
+type KeystoreConversionResource struct {
+	runner KeytoolRunner
+}
+
+// KeystoreConversionResourceModel describes the resource data model.
+type KeystoreConversionResourceModel struct {
+	Id              types.String `tfsdk:"id"`
+	SourceKeystore  types.String `tfsdk:"source_keystore"`
+	SourcePassword  types.String `tfsdk:"source_password"`
+	SourceStoreType types.String `tfsdk:"source_store_type"`
+	Aliases         types.List   `tfsdk:"aliases"`
+	TargetStoreType types.String `tfsdk:"target_store_type"`
+	TargetPassword  types.String `tfsdk:"target_password"`
+	File            types.String `tfsdk:"file"`
+}
+
+var conversionTargetStoreTypes = []string{StoreTypePKCS12, StoreTypeJKS, StoreTypeJCEKS, StoreTypePEM}
+
+// Convert runs the conversion described by the model with runner and
+// returns the base64 encoded result. An unset source store type is
+// detected and recorded in the model.
+func (r *KeystoreConversionResourceModel) Convert(ctx context.Context, runner KeytoolRunner) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	targetStoreType := strings.ToUpper(r.TargetStoreType.ValueString())
+	if !slices.Contains(conversionTargetStoreTypes, targetStoreType) {
+		diags.AddError("Unsupported target store type", fmt.Sprintf("target_store_type must be one of %s, got %q", strings.Join(conversionTargetStoreTypes, ", "), r.TargetStoreType.ValueString()))
+		return "", diags
+	}
+	if targetStoreType != StoreTypePEM && r.TargetPassword.ValueString() == "" {
+		diags.AddError("Missing target password", fmt.Sprintf("target_password is required when converting to %s", targetStoreType))
+		return "", diags
+	}
+
+	var aliases []string
+	diags.Append(r.Aliases.ElementsAs(ctx, &aliases, false)...)
+	if diags.HasError() {
+		return "", diags
+	}
+
+	if r.SourceStoreType.IsNull() || r.SourceStoreType.IsUnknown() {
+		storeType, err := detectBase64StoreType(r.SourceKeystore.ValueString())
+		if err != nil {
+			addKeystoreError(&diags, "conversion", err, keystoreErrorPaths{
+				ErrCorruptKeystore: path.Root("source_keystore"),
+			})
+			return "", diags
+		}
+		r.SourceStoreType = types.StringValue(storeType)
+	}
+
+	source := KeystoreModel{
+		Password: r.SourcePassword.ValueString(),
+		File:     r.SourceKeystore.ValueString(),
+		Runner:   runner,
+	}
+
+	b64File, err := source.ConvertKeystoreBase64(ctx, strings.ToUpper(r.SourceStoreType.ValueString()), targetStoreType, r.TargetPassword.ValueString(), aliases)
+	if err != nil {
+		addKeystoreError(&diags, "conversion", err, keystoreErrorPaths{
+			ErrIncorrectPassword:    path.Root("source_password"),
+			ErrCorruptKeystore:      path.Root("source_keystore"),
+			ErrUnsupportedAlgorithm: path.Root("source_keystore"),
+			ErrAliasNotFound:        path.Root("aliases"),
+		})
+	}
+	return b64File, diags
+}
+
+func (r *KeystoreConversionResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_keystore_conversion"
+}
+
+func (r *KeystoreConversionResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		Description: `
+        Converts a keystore to another store type under a new password using the keytool utility.
+        The target can be PKCS12, JKS, JCEKS or PEM. A PEM target is a bundle of unencrypted PKCS#8 private keys, each followed by its certificate chain, and trusted certificates.
+        The machine running Terraform needs to have the keytool utility installed.
+        `,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Generated UUID for the conversion",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"source_keystore": schema.StringAttribute{
+				Description: "Base64 encoded keystore to convert, such as the file attribute of a jks_keystore",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"source_password": schema.StringAttribute{
+				Description: "Password for the source keystore and its keys",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"source_store_type": schema.StringAttribute{
+				Description: "Store type of the source keystore: PKCS12, JKS or JCEKS, ignoring case. Detected from the content of the source keystore when unset",
+				Optional:    true,
+				Computed:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOfCaseInsensitive(StoreTypePKCS12, StoreTypeJKS, StoreTypeJCEKS),
+				},
+				PlanModifiers: []planmodifier.String{
+					sourceStoreTypeFromKeystore{},
+				},
+			},
+			"aliases": schema.ListAttribute{
+				Description: "Aliases of the entries to convert. All entries are converted when unset",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"target_store_type": schema.StringAttribute{
+				Description: "Store type to convert to: PKCS12, JKS, JCEKS or PEM, ignoring case",
+				Required:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOfCaseInsensitive(conversionTargetStoreTypes...),
+				},
+			},
+			"target_password": schema.StringAttribute{
+				Description: "Password for the converted keystore and its keys. Required unless target_store_type is PEM",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"file": schema.StringAttribute{
+				Description: "Base64 encoded converted keystore, or PEM bundle",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (r *KeystoreConversionResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	runner, diags := keytoolRunnerFromProviderData(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.runner = runner
+}
+
+func (r *KeystoreConversionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data KeystoreConversionResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	b64File, diags := data.Convert(ctx, r.runner)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+	data.File = types.StringValue(b64File)
+
+	tflog.Trace(ctx, "converted a keystore")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KeystoreConversionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data KeystoreConversionResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KeystoreConversionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data KeystoreConversionResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	b64File, diags := data.Convert(ctx, r.runner)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.File = types.StringValue(b64File)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KeystoreConversionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data KeystoreConversionResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 func NewKeystoreDataSource() datasource.DataSource {
@@ -19,6 +21,11 @@ type KeystoreDataSource struct {
 
 // KeystoreDataSourceModel describes the data source data model.
 type KeystoreDataSourceModel struct {
+	Keystore  types.String `tfsdk:"keystore"`
+	Password  types.String `tfsdk:"password"`
+	StoreType types.String `tfsdk:"store_type"`
+	Aliases   types.List   `tfsdk:"aliases"`
+	Entries   types.List   `tfsdk:"entries"`
 }
 
 func (d *KeystoreDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -28,9 +35,77 @@ func (d *KeystoreDataSource) Metadata(ctx context.Context, req datasource.Metada
 func (d *KeystoreDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
-		MarkdownDescription: "Not implemented.",
+		Description: `
+        Lists the entries of a PKCS12, JKS or JCEKS keystore with the details of their certificates.
+        The keystore is decoded by the provider itself and does not require the keytool utility.
+        `,
 
-		Attributes: map[string]schema.Attribute{},
+		Attributes: map[string]schema.Attribute{
+			"keystore": schema.StringAttribute{
+				Description: "Base64 encoded keystore, such as the file attribute of a jks_keystore",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"password": schema.StringAttribute{
+				Description: "Password for the keystore and its keys. Leave unset for a password-less truststore",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"store_type": schema.StringAttribute{
+				Description: "Store type of the keystore: PKCS12, JKS or JCEKS. Detected from the content of the keystore when unset",
+				Optional:    true,
+				Computed:    true,
+			},
+			"aliases": schema.ListAttribute{
+				Description: "Aliases of all entries, sorted",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"entries": schema.ListNestedAttribute{
+				Description: "Entries of the keystore, sorted by alias",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"alias": schema.StringAttribute{
+							Description: "Alias of the entry",
+							Computed:    true,
+						},
+						"type": schema.StringAttribute{
+							Description: "Entry type as keytool -list prints it: PrivateKeyEntry, trustedCertEntry or SecretKeyEntry",
+							Computed:    true,
+						},
+						"subject": schema.StringAttribute{
+							Description: "Subject of the certificate",
+							Computed:    true,
+						},
+						"issuer": schema.StringAttribute{
+							Description: "Issuer of the certificate",
+							Computed:    true,
+						},
+						"serial_number": schema.StringAttribute{
+							Description: "Hex encoded serial number of the certificate",
+							Computed:    true,
+						},
+						"not_before": schema.StringAttribute{
+							Description: "Start of the validity of the certificate, in RFC 3339 format",
+							Computed:    true,
+						},
+						"not_after": schema.StringAttribute{
+							Description: "Expiry of the certificate, in RFC 3339 format",
+							Computed:    true,
+						},
+						"fingerprint_sha256": schema.StringAttribute{
+							Description: "Hex encoded SHA-256 fingerprint of the certificate",
+							Computed:    true,
+						},
+						"chain_length": schema.Int64Attribute{
+							Description: "Number of certificates in the chain of the entry",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -51,6 +126,31 @@ func (d *KeystoreDataSource) Read(ctx context.Context, req datasource.ReadReques
 		return
 	}
 
+	ks, err := InspectKeystore(ctx, data.Keystore.ValueString(), data.StoreType.ValueString(), data.Password.ValueString())
+	if err != nil {
+		addKeystoreError(&resp.Diagnostics, "read", err, keystoreErrorPaths{
+			ErrIncorrectPassword:    path.Root("password"),
+			ErrCorruptKeystore:      path.Root("keystore"),
+			ErrUnsupportedAlgorithm: path.Root("keystore"),
+		})
+		return
+	}
+
+	aliases, diags := types.ListValueFrom(ctx, types.StringType, ks.Aliases())
+	resp.Diagnostics.Append(diags...)
+	entries, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: keystoreEntryAttrTypes}, ks.EntryModels())
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.StoreType.IsNull() {
+		data.StoreType = types.StringValue(ks.StoreType)
+	}
+	data.Aliases = aliases
+	data.Entries = entries
+
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -4,26 +4,117 @@
 package provider
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 )
 
 func TestAccKeystoreDataSource(t *testing.T) {
+	ca := testFixtureEntry(t, "testdata/go-pkcs12-truststore.p12", "changeit", "test ca")
+	passwordless, err := encodePKCS12([]KeystoreEntry{*ca}, "", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			// Read testing
 			{
-				Config: testAccExampleDataSourceConfig,
+				Config: testAccKeystoreDataSourceConfig(testFixtureBase64(t, "testdata/openssl-modern.p12"), "changeit", ""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.jks_keystore.test", tfjsonpath.New("store_type"), knownvalue.StringExact(StoreTypePKCS12)),
+					statecheck.ExpectKnownValue("data.jks_keystore.test", tfjsonpath.New("aliases"), knownvalue.ListExact([]knownvalue.Check{
+						knownvalue.StringExact("server"),
+					})),
+					statecheck.ExpectKnownValue("data.jks_keystore.test", tfjsonpath.New("entries").AtSliceIndex(0), knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"alias":        knownvalue.StringExact("server"),
+						"type":         knownvalue.StringExact(EntryTypePrivateKey),
+						"subject":      knownvalue.StringExact("CN=leaf.example.com"),
+						"issuer":       knownvalue.StringExact("CN=Test CA"),
+						"chain_length": knownvalue.Int64Exact(2),
+					})),
+				},
+			},
+			{
+				Config: testAccKeystoreDataSourceConfig(testFixtureBase64(t, "testdata/keystore-go.jks"), "password", "JKS"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.jks_keystore.test", tfjsonpath.New("entries").AtSliceIndex(0), knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"alias":   knownvalue.StringExact("alias"),
+						"subject": knownvalue.Null(),
+					})),
+				},
+			},
+			// The store type is detected when unset.
+			{
+				Config: testAccKeystoreDataSourceConfig(testFixtureBase64(t, "testdata/keystore-go.jks"), "password", ""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.jks_keystore.test", tfjsonpath.New("store_type"), knownvalue.StringExact(StoreTypeJKS)),
+				},
+			},
+			// A password-less truststore is read without a password.
+			{
+				Config: testAccKeystoreDataSourceConfig(base64.StdEncoding.EncodeToString(passwordless), "", ""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.jks_keystore.test", tfjsonpath.New("entries").AtSliceIndex(0), knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"alias": knownvalue.StringExact("test ca"),
+						"type":  knownvalue.StringExact(EntryTypeTrustedCertificate),
+					})),
+				},
+			},
+			{
+				Config:      testAccKeystoreDataSourceConfig(testFixtureBase64(t, "testdata/openssl-modern.p12"), "wrong-password", ""),
+				ExpectError: regexp.MustCompile("keystore password was incorrect"),
 			},
 		},
 	})
 }
 
-const testAccExampleDataSourceConfig = `
+func testAccKeystoreDataSourceConfig(keystore, password, storeType string) string {
+	config := fmt.Sprintf(`
 data "jks_keystore" "test" {
-  
+  keystore = %q
+`, keystore)
+	if password != "" {
+		config += fmt.Sprintf("  password = %q\n", password)
+	}
+	if storeType != "" {
+		config += fmt.Sprintf("  store_type = %q\n", storeType)
+	}
+	return config + "}\n"
+}
+
+// testFixtureBase64 returns a file from testdata base64 encoded.
+func testFixtureBase64(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// testFixtureEntry decodes an entry of a PKCS12 file from testdata.
+func testFixtureEntry(t *testing.T, name, password, alias string) *KeystoreEntry {
+	t.Helper()
+
+	ks, err := InspectKeystore(context.Background(), testFixtureBase64(t, name), StoreTypePKCS12, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := ks.Entry(alias)
+	if !ok {
+		t.Fatalf("entry %s not found in %s", alias, name)
+	}
+	return entry
 }
-`
@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Store types accepted by keytool. PEM is only produced by conversions.
+const (
+	StoreTypePKCS12 = "PKCS12"
+	StoreTypeJKS    = "JKS"
+	StoreTypeJCEKS  = "JCEKS"
+	StoreTypePEM    = "PEM"
+)
+
+// Entry types, named as keytool -list prints them.
+const (
+	EntryTypePrivateKey         = "PrivateKeyEntry"
+	EntryTypeTrustedCertificate = "trustedCertEntry"
+	EntryTypeSecretKey          = "SecretKeyEntry"
+)
+
+// ErrIncorrectPassword is returned when the keystore integrity check or the
+// decryption of an entry fails with the supplied password.
+var ErrIncorrectPassword = errors.New("keystore password was incorrect")
+
+// maxIterations bounds the iteration counts of key derivations read from a
+// keystore, so that a crafted file cannot keep the provider hashing for
+// hours. Java refuses keystores above the same limit.
+var maxIterations = 5000000
+
+// checkIterations rejects iteration counts keytool would not have written.
+func checkIterations(n int) error {
+	if n < 1 || n > maxIterations {
+		return fmt.Errorf("iteration count %d is out of range", n)
+	}
+	return nil
+}
+
+// KeystoreEntry is a single aliased entry of a decoded keystore.
+type KeystoreEntry struct {
+	Alias string
+	Type  string
+	// Key is the PKCS#8 encoded private key of a PrivateKeyEntry.
+	Key []byte
+	// Certificates is the chain of a PrivateKeyEntry, leaf first, or the
+	// single certificate of a trustedCertEntry.
+	Certificates []*x509.Certificate
+}
+
+// DecodedKeystore is the content of a keystore read in Go, without keytool.
+type DecodedKeystore struct {
+	StoreType string
+	// Entries are sorted by alias.
+	Entries []KeystoreEntry
+}
+
+// DetectStoreType tells the store type of a keystore from its content. JKS
+// and JCEKS keystores start with a magic number, and a PKCS12 keystore is
+// the DER encoding of a version 3 PFX, whose content is data or signed data.
+func DetectStoreType(data []byte) (string, error) {
+	if len(data) >= 4 {
+		switch binary.BigEndian.Uint32(data) {
+		case jksMagic:
+			return StoreTypeJKS, nil
+		case jceksMagic:
+			return StoreTypeJCEKS, nil
+		}
+	}
+	var pfx pfxPdu
+	if err := unmarshalDER(data, &pfx); err == nil && pfx.Version == 3 &&
+		(pfx.AuthSafe.ContentType.Equal(oidDataContentType) || pfx.AuthSafe.ContentType.Equal(oidSignedDataContentType)) {
+		return StoreTypePKCS12, nil
+	}
+	return "", newKeystoreError(ErrCorruptKeystore, "keystore is not a PKCS12, JKS or JCEKS keystore")
+}
+
+// DecodeKeystore decodes a keystore of the given store type. When
+// storeType is empty, it is detected from the content.
+func DecodeKeystore(data []byte, storeType, password string) (*DecodedKeystore, error) {
+	var entries []KeystoreEntry
+	var err error
+
+	if storeType == "" {
+		if storeType, err = DetectStoreType(data); err != nil {
+			return nil, err
+		}
+	}
+
+	switch strings.ToUpper(storeType) {
+	case StoreTypePKCS12:
+		entries, err = decodePKCS12(data, password)
+	case StoreTypeJKS, StoreTypeJCEKS:
+		entries, err = decodeJKS(data, strings.ToUpper(storeType), password)
+	default:
+		return nil, fmt.Errorf("decoding %s keystores is not supported", storeType)
+	}
+	switch {
+	case errors.Is(err, ErrIncorrectPassword), errors.Is(err, ErrUnsupportedAlgorithm):
+		return nil, err
+	case err != nil:
+		// Any other error means the keystore could not be parsed.
+		return nil, &KeystoreError{Kind: ErrCorruptKeystore, Err: err}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Alias < entries[j].Alias })
+	return &DecodedKeystore{StoreType: strings.ToUpper(storeType), Entries: entries}, nil
+}
+
+// Entry looks up an entry by alias. Like keytool, the lookup ignores case.
+func (k *DecodedKeystore) Entry(alias string) (*KeystoreEntry, bool) {
+	for i := range k.Entries {
+		if strings.EqualFold(k.Entries[i].Alias, alias) {
+			return &k.Entries[i], true
+		}
+	}
+	return nil, false
+}
+
+// PEM encodes the entry as an unencrypted PKCS#8 private key, if any,
+// followed by its certificates.
+func (e KeystoreEntry) PEM() (string, error) {
+	var b strings.Builder
+	switch e.Type {
+	case EntryTypePrivateKey:
+		_ = pem.Encode(&b, &pem.Block{Type: "PRIVATE KEY", Bytes: e.Key})
+	case EntryTypeTrustedCertificate:
+	default:
+		return "", fmt.Errorf("entry %q is a %s and cannot be written as PEM", e.Alias, e.Type)
+	}
+	b.WriteString(EncodeCertificatesPEM(e.Certificates))
+	return b.String(), nil
+}
+
+// buildChain orders the certificate chain starting at leaf by following
+// issuer names through pool, the way Java rebuilds chains on load.
+func buildChain(leaf *x509.Certificate, pool []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	used := make([]bool, len(pool))
+	for current := leaf; !isSelfIssued(current); {
+		next := -1
+		for i, candidate := range pool {
+			if !used[i] && string(candidate.RawSubject) == string(current.RawIssuer) && !candidate.Equal(current) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		used[next] = true
+		current = pool[next]
+		chain = append(chain, current)
+	}
+	return chain
+}
+
+func isSelfIssued(cert *x509.Certificate) bool {
+	return string(cert.RawSubject) == string(cert.RawIssuer)
+}
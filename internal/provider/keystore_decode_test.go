@@ -0,0 +1,331 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"os"
+	"testing"
+)
+
+// The PKCS12 fixtures were written by OpenSSL 3 with the password
+// "changeit": an EC key for leaf.example.com under the alias "server",
+// issued by "Test CA". openssl-legacy.p12 uses -legacy encryption.
+func TestDecodeKeystorePKCS12(t *testing.T) {
+	for _, name := range []string{"testdata/openssl-modern.p12", "testdata/openssl-legacy.p12"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ks, err := DecodeKeystore(data, StoreTypePKCS12, "changeit")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ks.Entries) != 1 {
+				t.Fatalf("expected 1 entry, found %d", len(ks.Entries))
+			}
+
+			entry, ok := ks.Entry("SERVER")
+			if !ok {
+				t.Fatal("entry server not found")
+			}
+			if entry.Type != EntryTypePrivateKey {
+				t.Errorf("entry type is %s, expected %s", entry.Type, EntryTypePrivateKey)
+			}
+			if len(entry.Certificates) != 2 {
+				t.Fatalf("expected a chain of 2 certificates, found %d", len(entry.Certificates))
+			}
+			if cn := entry.Certificates[0].Subject.CommonName; cn != "leaf.example.com" {
+				t.Errorf("leaf common name is %s", cn)
+			}
+			if cn := entry.Certificates[1].Subject.CommonName; cn != "Test CA" {
+				t.Errorf("issuer common name is %s", cn)
+			}
+
+			key, err := x509.ParsePKCS8PrivateKey(entry.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !PublicKeysEqual(entry.Certificates[0].PublicKey, publicKey(key)) {
+				t.Error("private key does not match the leaf certificate")
+			}
+
+			if _, err := DecodeKeystore(data, StoreTypePKCS12, "wrong-password"); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("expected ErrIncorrectPassword, got %v", err)
+			}
+		})
+	}
+}
+
+// keystore-go.jks comes from the test data of
+// github.com/pavlo-v-chernykh/keystore-go, with the password "password":
+// a private key entry "alias" without a certificate chain, whose key is
+// keystore-go-key.pem.
+func TestDecodeKeystoreJKS(t *testing.T) {
+	data, err := os.ReadFile("testdata/keystore-go.jks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := os.ReadFile("testdata/keystore-go-key.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := DecodeKeystore(data, StoreTypeJKS, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := ks.Entry("ALIAS")
+	if !ok {
+		t.Fatal("entry alias not found")
+	}
+	if entry.Type != EntryTypePrivateKey {
+		t.Errorf("entry type is %s, expected %s", entry.Type, EntryTypePrivateKey)
+	}
+	if block, _ := pem.Decode(keyPEM); !bytes.Equal(entry.Key, block.Bytes) {
+		t.Error("decrypted private key does not match keystore-go-key.pem")
+	}
+
+	if _, err := DecodeKeystore(data, StoreTypeJKS, "wrong-password"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("expected ErrIncorrectPassword, got %v", err)
+	}
+	if _, err := DecodeKeystore(data, StoreTypeJCEKS, "password"); err == nil {
+		t.Error("expected an error decoding a JKS keystore as JCEKS")
+	}
+	if _, err := DecodeKeystore(data[:len(data)-1], StoreTypeJKS, "password"); err == nil {
+		t.Error("expected an error decoding a truncated keystore")
+	}
+}
+
+// TestDecodeKeystoreJKSEntries round-trips the PKCS12 fixture through JKS
+// and JCEKS keystores written the way keytool writes them, with a trusted
+// certificate next to the key entry.
+func TestDecodeKeystoreJKSEntries(t *testing.T) {
+	data, err := os.ReadFile("testdata/openssl-modern.p12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := DecodeKeystore(data, StoreTypePKCS12, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := source.Entries[0]
+	ca := KeystoreEntry{
+		Alias:        "ca",
+		Type:         EntryTypeTrustedCertificate,
+		Certificates: server.Certificates[1:],
+	}
+
+	for _, storeType := range []string{StoreTypeJKS, StoreTypeJCEKS} {
+		t.Run(storeType, func(t *testing.T) {
+			encoded := testEncodeJKS(t, storeType, []KeystoreEntry{server, ca}, "changeit")
+
+			ks, err := DecodeKeystore(encoded, storeType, "changeit")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ks.Entries) != 2 {
+				t.Fatalf("expected 2 entries, found %d", len(ks.Entries))
+			}
+
+			entry, ok := ks.Entry("server")
+			if !ok {
+				t.Fatal("entry server not found")
+			}
+			if !bytes.Equal(entry.Key, server.Key) {
+				t.Error("decrypted private key does not match")
+			}
+			if len(entry.Certificates) != 2 || !entry.Certificates[1].Equal(server.Certificates[1]) {
+				t.Error("certificate chain does not match")
+			}
+
+			entry, ok = ks.Entry("ca")
+			if !ok {
+				t.Fatal("entry ca not found")
+			}
+			if entry.Type != EntryTypeTrustedCertificate || !entry.Certificates[0].Equal(server.Certificates[1]) {
+				t.Error("trusted certificate does not match")
+			}
+
+			if _, err := DecodeKeystore(encoded, storeType, "wrong-password"); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("expected ErrIncorrectPassword, got %v", err)
+			}
+		})
+	}
+}
+
+// testEncodeJKS writes entries as a JKS or JCEKS keystore. Private keys are
+// protected with the algorithm keytool uses for the store type.
+func testEncodeJKS(t testing.TB, storeType string, entries []KeystoreEntry, password string) []byte {
+	t.Helper()
+
+	magic := uint32(jksMagic)
+	if storeType == StoreTypeJCEKS {
+		magic = jceksMagic
+	}
+
+	var b bytes.Buffer
+	writeUTF := func(s string) {
+		_ = binary.Write(&b, binary.BigEndian, uint16(len(s)))
+		b.WriteString(s)
+	}
+	writeCertificate := func(cert *x509.Certificate) {
+		writeUTF("X.509")
+		_ = binary.Write(&b, binary.BigEndian, uint32(len(cert.Raw)))
+		b.Write(cert.Raw)
+	}
+
+	for _, v := range []uint32{magic, 2, uint32(len(entries))} {
+		_ = binary.Write(&b, binary.BigEndian, v)
+	}
+	for _, entry := range entries {
+		switch entry.Type {
+		case EntryTypePrivateKey:
+			_ = binary.Write(&b, binary.BigEndian, uint32(jksTagPrivateKey))
+			writeUTF(entry.Alias)
+			_ = binary.Write(&b, binary.BigEndian, uint64(0))
+			protected := testProtectJKSKey(t, storeType, entry.Key, password)
+			_ = binary.Write(&b, binary.BigEndian, uint32(len(protected)))
+			b.Write(protected)
+			_ = binary.Write(&b, binary.BigEndian, uint32(len(entry.Certificates)))
+			for _, cert := range entry.Certificates {
+				writeCertificate(cert)
+			}
+		case EntryTypeTrustedCertificate:
+			_ = binary.Write(&b, binary.BigEndian, uint32(jksTagTrustedCert))
+			writeUTF(entry.Alias)
+			_ = binary.Write(&b, binary.BigEndian, uint64(0))
+			writeCertificate(entry.Certificates[0])
+		}
+	}
+
+	h := sha1.New()
+	h.Write(utf16BEPassword(password))
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(b.Bytes())
+	b.Write(h.Sum(nil))
+	return b.Bytes()
+}
+
+func testProtectJKSKey(t testing.TB, storeType string, key []byte, password string) []byte {
+	t.Helper()
+
+	var alg pkix.AlgorithmIdentifier
+	var encrypted []byte
+
+	if storeType == StoreTypeJKS {
+		salt := bytes.Repeat([]byte{7}, sha1.Size)
+		passwd := utf16BEPassword(password)
+		encrypted = append(encrypted, salt...)
+		digest := salt
+		for i := 0; i < len(key); i += sha1.Size {
+			sum := sha1.Sum(append(append([]byte{}, passwd...), digest...))
+			digest = sum[:]
+			for j := 0; j < sha1.Size && i+j < len(key); j++ {
+				encrypted = append(encrypted, key[i+j]^digest[j])
+			}
+		}
+		check := sha1.Sum(append(append([]byte{}, passwd...), key...))
+		encrypted = append(encrypted, check[:]...)
+		alg = pkix.AlgorithmIdentifier{Algorithm: oidJKSKeyProtector, Parameters: asn1.NullRawValue}
+	} else {
+		params := pbeParams{Salt: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Iterations: 1000}
+		var derived []byte
+		for i := 0; i < 2; i++ {
+			digest := params.Salt[i*4 : i*4+4]
+			for j := 0; j < params.Iterations; j++ {
+				sum := md5.Sum(append(append([]byte{}, digest...), password...))
+				digest = sum[:]
+			}
+			derived = append(derived, digest...)
+		}
+		block, err := des.NewTripleDESCipher(derived[:24])
+		if err != nil {
+			t.Fatal(err)
+		}
+		padding := des.BlockSize - len(key)%des.BlockSize
+		plaintext := append(append([]byte{}, key...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		encrypted = make([]byte, len(plaintext))
+		cipher.NewCBCEncrypter(block, derived[24:]).CryptBlocks(encrypted, plaintext)
+
+		der, err := asn1.Marshal(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alg = pkix.AlgorithmIdentifier{Algorithm: oidPBEWithMD5AndTripleDESCBC, Parameters: asn1.RawValue{FullBytes: der}}
+	}
+
+	protected, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: alg, EncryptedData: encrypted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return protected
+}
+
+func TestDetectStoreType(t *testing.T) {
+	passwordless, err := encodePKCS12([]KeystoreEntry{testTrustedEntry(t, "root", "Root CA")}, "", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystores := map[string][]byte{
+		StoreTypeJCEKS: testEncodeJKS(t, StoreTypeJCEKS, []KeystoreEntry{testTrustedEntry(t, "root", "Root CA")}, "changeit"),
+		"passwordless": passwordless,
+	}
+	for _, name := range []string{"testdata/openssl-modern.p12", "testdata/openssl-legacy.p12", "testdata/go-pkcs12-truststore.p12", "testdata/keystore-go.jks"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keystores[name] = data
+	}
+	expected := map[string]string{
+		StoreTypeJCEKS:                      StoreTypeJCEKS,
+		"passwordless":                      StoreTypePKCS12,
+		"testdata/openssl-modern.p12":       StoreTypePKCS12,
+		"testdata/openssl-legacy.p12":       StoreTypePKCS12,
+		"testdata/go-pkcs12-truststore.p12": StoreTypePKCS12,
+		"testdata/keystore-go.jks":          StoreTypeJKS,
+	}
+	for name, data := range keystores {
+		t.Run(name, func(t *testing.T) {
+			storeType, err := DetectStoreType(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if storeType != expected[name] {
+				t.Errorf("expected %s, got %s", expected[name], storeType)
+			}
+			// Decoding without a store type uses the detected one.
+			ks, err := DecodeKeystore(data, "", "changeit")
+			if err == nil && ks.StoreType != expected[name] {
+				t.Errorf("expected a %s keystore, got %s", expected[name], ks.StoreType)
+			}
+		})
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":       nil,
+		"PEM":         []byte(testSelfSignedCertificatePEM(t, "Root CA")),
+		"certificate": testTrustedEntry(t, "root", "Root CA").Certificates[0].Raw,
+		"truncated":   keystores["testdata/openssl-modern.p12"][:100],
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DetectStoreType(data); !errors.Is(err, ErrCorruptKeystore) {
+				t.Errorf("expected ErrCorruptKeystore, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// dnSpecialChars are the characters that end or change the meaning of an
+// unquoted RFC 1779 attribute value, as keytool parses -dname.
+const dnSpecialChars = ",;+=<>#\\\"\n"
+
+// quoteDNValue quotes v when it cannot be written as is in a -dname.
+func quoteDNValue(v string) string {
+	if !strings.ContainsAny(v, dnSpecialChars) && strings.TrimSpace(v) == v {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(v) + `"`
+}
+
+// ParseDistinguishedName parses a distinguished name in the format keytool
+// accepts for -dname, such as the output of DistinguishedName.String.
+// Attribute values may be quoted or contain backslash escapes. Only the
+// attributes of DistinguishedName are accepted, each at most once.
+func ParseDistinguishedName(s string) (DistinguishedName, error) {
+	var d DistinguishedName
+	fields := map[string]*string{
+		"CN": &d.CommonName,
+		"OU": &d.OrganizationalUnit,
+		"O":  &d.Organization,
+		"L":  &d.Locality,
+		"S":  &d.State,
+		"ST": &d.State,
+		"C":  &d.Country,
+	}
+	seen := map[*string]bool{}
+
+	if strings.TrimSpace(s) == "" {
+		return d, errors.New("distinguished name is empty")
+	}
+	p := dnParser{s: s}
+	for {
+		key, ok := p.key()
+		if !ok {
+			return d, fmt.Errorf("expected an attribute type at offset %d", p.i)
+		}
+		field, ok := fields[strings.ToUpper(key)]
+		if !ok {
+			return d, fmt.Errorf("unsupported attribute type %q", key)
+		}
+		if seen[field] {
+			return d, fmt.Errorf("attribute %s is given more than once", key)
+		}
+		seen[field] = true
+
+		value, err := p.value()
+		if err != nil {
+			return d, fmt.Errorf("error reading the value of %s: %w", key, err)
+		}
+		*field = value
+
+		if p.done() {
+			return d, nil
+		}
+		switch c := p.s[p.i]; c {
+		case ',', ';':
+			p.i++
+		case '+':
+			return d, errors.New("multi-valued relative distinguished names are not supported")
+		default:
+			return d, fmt.Errorf("unexpected %q at offset %d", c, p.i)
+		}
+	}
+}
+
+// dnParser reads the attributes of a distinguished name in turn.
+type dnParser struct {
+	s string
+	i int
+}
+
+func (p *dnParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *dnParser) skipSpaces() {
+	for !p.done() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+// key reads an attribute type and the equals sign after it.
+func (p *dnParser) key() (string, bool) {
+	p.skipSpaces()
+	start := p.i
+	for !p.done() && isDNKeyChar(p.s[p.i]) {
+		p.i++
+	}
+	key := p.s[start:p.i]
+	p.skipSpaces()
+	if key == "" || p.done() || p.s[p.i] != '=' {
+		return "", false
+	}
+	p.i++
+	return key, true
+}
+
+func isDNKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.'
+}
+
+// value reads a quoted or unquoted attribute value, up to the next
+// separator. Unescaped spaces around an unquoted value are dropped.
+func (p *dnParser) value() (string, error) {
+	p.skipSpaces()
+	var b strings.Builder
+
+	if !p.done() && p.s[p.i] == '"' {
+		p.i++
+		for {
+			if p.done() {
+				return "", errors.New("unterminated quoted value")
+			}
+			c := p.s[p.i]
+			p.i++
+			switch c {
+			case '"':
+				p.skipSpaces()
+				return b.String(), nil
+			case '\\':
+				if p.done() {
+					return "", errors.New("unterminated escape sequence")
+				}
+				c = p.s[p.i]
+				p.i++
+			}
+			b.WriteByte(c)
+		}
+	}
+
+	// keep is the length of the value without its unescaped trailing spaces.
+	keep := 0
+	for !p.done() {
+		c := p.s[p.i]
+		switch {
+		case c == ',' || c == ';' || c == '+':
+			return b.String()[:keep], nil
+		case c == '\\':
+			p.i++
+			if p.done() {
+				return "", errors.New("unterminated escape sequence")
+			}
+			b.WriteByte(p.s[p.i])
+			keep = b.Len()
+		case strings.IndexByte(dnSpecialChars, c) >= 0:
+			return "", fmt.Errorf("unexpected %q, special characters must be escaped or quoted", c)
+		default:
+			b.WriteByte(c)
+			if c != ' ' {
+				keep = b.Len()
+			}
+		}
+		p.i++
+	}
+	return b.String()[:keep], nil
+}
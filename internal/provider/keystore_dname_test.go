@@ -0,0 +1,78 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+)
+
+func TestParseDistinguishedName(t *testing.T) {
+	tests := []struct {
+		dname    string
+		expected DistinguishedName
+	}{
+		{"CN=service.example.com", DistinguishedName{CommonName: "service.example.com"}},
+		{
+			"CN=service.example.com, OU=, O=Example, L=Amsterdam, S=Noord-Holland, C=NL",
+			DistinguishedName{CommonName: "service.example.com", Organization: "Example", Locality: "Amsterdam", State: "Noord-Holland", Country: "NL"},
+		},
+		{"cn = spaced ; st=Utrecht", DistinguishedName{CommonName: "spaced", State: "Utrecht"}},
+		{`O="Example, Inc."`, DistinguishedName{Organization: "Example, Inc."}},
+		{`O=Example\, Inc.`, DistinguishedName{Organization: "Example, Inc."}},
+		{`OU=" padded "`, DistinguishedName{OrganizationalUnit: " padded "}},
+		{`OU=trailing\ `, DistinguishedName{OrganizationalUnit: "trailing "}},
+		{`CN="say \"hi\""`, DistinguishedName{CommonName: `say "hi"`}},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDistinguishedName(tt.dname)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.dname, err)
+			continue
+		}
+		if d != tt.expected {
+			t.Errorf("expected %+v for %q, got %+v", tt.expected, tt.dname, d)
+		}
+	}
+}
+
+func TestParseDistinguishedNameErrors(t *testing.T) {
+	for _, dname := range []string{
+		"",
+		"service.example.com",
+		"CN=a, CN=b",
+		"CN=a, ST=b, S=c",
+		"CN=a+OU=b",
+		"DC=example",
+		`CN="unterminated`,
+		`CN=escape\`,
+		"CN=a#b",
+		`CN="quoted" trailing`,
+	} {
+		if d, err := ParseDistinguishedName(dname); err == nil {
+			t.Errorf("expected an error for %q, got %+v", dname, d)
+		}
+	}
+}
+
+func TestDistinguishedNameString(t *testing.T) {
+	d := DistinguishedName{
+		CommonName:   "service.example.com",
+		Organization: `Example, "Inc."`,
+		Locality:     " Den Haag",
+		Country:      "NL",
+	}
+
+	expected := `CN=service.example.com, OU=, O="Example, \"Inc.\"", L=" Den Haag", S=, C=NL`
+	if s := d.String(); s != expected {
+		t.Errorf("expected %s, got %s", expected, s)
+	}
+	parsed, err := ParseDistinguishedName(d.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != d {
+		t.Errorf("expected %+v, got %+v", d, parsed)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &KeystoreEntryResource{}
+var _ resource.ResourceWithConfigure = &KeystoreEntryResource{}
+
+func NewKeystoreEntryResource() resource.Resource {
+	return &KeystoreEntryResource{}
+}
+
+// KeystoreEntryResource defines the resource implementation.
+type KeystoreEntryResource struct {
+	runner KeytoolRunner
+}
+
+// KeystoreEntryResourceModel describes the resource data model.
+type KeystoreEntryResourceModel struct {
+	Id                types.String `tfsdk:"id"`
+	KeystorePath      types.String `tfsdk:"keystore_path"`
+	Password          types.String `tfsdk:"password"`
+	StoreType         types.String `tfsdk:"store_type"`
+	Alias             types.String `tfsdk:"alias"`
+	CertificatePem    types.String `tfsdk:"certificate_pem"`
+	PrivateKeyPem     types.String `tfsdk:"private_key_pem"`
+	EntryType         types.String `tfsdk:"entry_type"`
+	FingerprintSha256 types.String `tfsdk:"fingerprint_sha256"`
+}
+
+// File returns the keystore file the entry is kept in.
+func (r KeystoreEntryResourceModel) File(runner KeytoolRunner) KeystoreFile {
+	return KeystoreFile{
+		Path:      r.KeystorePath.ValueString(),
+		StoreType: r.StoreType.ValueString(),
+		Password:  r.Password.ValueString(),
+		Runner:    runner,
+	}
+}
+
+// ExpectedEntry returns the entry_type and fingerprint_sha256 of the entry
+// the configuration describes, or unknown values while they cannot be told.
+func (r KeystoreEntryResourceModel) ExpectedEntry() (types.String, types.String) {
+	entryType, fingerprint := types.StringUnknown(), types.StringUnknown()
+
+	switch {
+	case r.PrivateKeyPem.IsUnknown():
+	case r.PrivateKeyPem.IsNull():
+		entryType = types.StringValue(EntryTypeTrustedCertificate)
+	default:
+		entryType = types.StringValue(EntryTypePrivateKey)
+	}
+
+	if !r.CertificatePem.IsUnknown() {
+		// Invalid certificates are reported when the entry is written.
+		if certs, err := ParseCertificatesPEM(r.CertificatePem.ValueString()); err == nil && len(certs) > 0 {
+			fingerprint = types.StringValue(SHA256Hex(certs[0].Raw))
+		}
+	}
+	return entryType, fingerprint
+}
+
+// Write puts the configured entry into the keystore file, replacing any
+// entry of the same alias, and records the entry written.
+func (r *KeystoreEntryResourceModel) Write(ctx context.Context, runner KeytoolRunner) error {
+	entry, err := NewKeystoreFileEntry(r.Alias.ValueString(), r.CertificatePem.ValueString(), r.PrivateKeyPem.ValueString())
+	if err != nil {
+		return err
+	}
+	if err := r.File(runner).PutEntry(ctx, entry); err != nil {
+		return err
+	}
+
+	found, err := r.Refresh(ctx)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return newKeystoreError(ErrAliasNotFound, "entry %q not found in %s after writing it", entry.Alias, r.KeystorePath.ValueString())
+	}
+	return nil
+}
+
+// Refresh records the store type of the keystore file and the type and
+// fingerprint of the entry found in it, and reports whether the file holds
+// the entry at all.
+func (r *KeystoreEntryResourceModel) Refresh(ctx context.Context) (bool, error) {
+	ks, err := r.File(nil).Read(ctx)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	entry, ok := ks.Entry(r.Alias.ValueString())
+	if !ok {
+		return false, nil
+	}
+
+	// A configured store type is kept as written, as it is accepted
+	// ignoring case.
+	if !strings.EqualFold(r.StoreType.ValueString(), ks.StoreType) {
+		r.StoreType = types.StringValue(ks.StoreType)
+	}
+	r.EntryType = types.StringValue(entry.Type)
+	r.FingerprintSha256 = types.StringValue("")
+	if len(entry.Certificates) > 0 {
+		r.FingerprintSha256 = types.StringValue(SHA256Hex(entry.Certificates[0].Raw))
+	}
+	return true, nil
+}
+
+// errorPaths maps error kinds to the attribute they are reported on.
+func (r KeystoreEntryResourceModel) errorPaths() keystoreErrorPaths {
+	return keystoreErrorPaths{
+		ErrIncorrectPassword:    path.Root("password"),
+		ErrCorruptKeystore:      path.Root("keystore_path"),
+		ErrUnsupportedAlgorithm: path.Root("keystore_path"),
+		ErrCertificateMismatch:  path.Root("certificate_pem"),
+	}
+}
+
+func (r *KeystoreEntryResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_keystore_entry"
+}
+
+func (r *KeystoreEntryResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		Description: `
+        Manages a single trusted certificate or private key entry of a keystore file on disk, leaving its other entries alone.
+        Every change reads the file, edits it with the keytool utility and replaces it atomically, keeping its permission and owner.
+        A password-less truststore is edited by the provider itself instead, as keytool cannot write one.
+        Changes are serialized by a lock on a file next to the keystore, named after it with a .lock suffix, so that several entries of one keystore can be managed concurrently. The lock file is left in place, also after the keystore file is removed.
+        An entry removed from the file outside of Terraform is planned to be added again.
+        `,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Generated UUID for the entry",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"keystore_path": schema.StringAttribute{
+				Description: "Path of the keystore file. The file is created if it does not exist",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"password": schema.StringAttribute{
+				Description: "Password of the keystore file, which also protects a private key entry, at least 6 characters long. " +
+					"Leave unset for a password-less PKCS12 truststore, as Java 18 and later and jks_truststore write, which holds trusted certificates only",
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(MinPasswordLength),
+				},
+			},
+			"store_type": schema.StringAttribute{
+				Description: "Store type of the keystore file: PKCS12, JKS or JCEKS, ignoring case. " +
+					"Detected from the content of the file when unset. A file created by the entry is PKCS12",
+				Optional: true,
+				Computed: true,
+				Validators: []validator.String{
+					stringvalidator.OneOfCaseInsensitive(StoreTypePKCS12, StoreTypeJKS, StoreTypeJCEKS),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"alias": schema.StringAttribute{
+				Description: "Alias of the entry. An existing entry of the same alias, ignoring case, is replaced",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"certificate_pem": schema.StringAttribute{
+				Description: "PEM encoded certificate of a trusted certificate entry, or the certificate chain of a private key entry, leaf first",
+				Required:    true,
+			},
+			"private_key_pem": schema.StringAttribute{
+				Description: "PEM encoded private key, which makes the entry a private key entry. It must match the first certificate of certificate_pem. Requires password",
+				Optional:    true,
+				Sensitive:   true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("password")),
+				},
+			},
+			"entry_type": schema.StringAttribute{
+				Description: "Type of the entry as keytool lists it: trustedCertEntry or PrivateKeyEntry",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keystoreEntryFromConfig{},
+				},
+			},
+			"fingerprint_sha256": schema.StringAttribute{
+				Description: "Hex encoded SHA-256 fingerprint of the certificate of the entry, the leaf of a private key entry",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keystoreEntryFromConfig{},
+				},
+			},
+		},
+	}
+}
+
+func (r *KeystoreEntryResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	runner, diags := keytoolRunnerFromProviderData(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.runner = runner
+}
+
+func (r *KeystoreEntryResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data KeystoreEntryResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := data.Write(ctx, r.runner); err != nil {
+		addKeystoreError(&resp.Diagnostics, "create", err, data.errorPaths())
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+
+	tflog.Trace(ctx, "created a keystore entry")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KeystoreEntryResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data KeystoreEntryResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	found, err := data.Refresh(ctx)
+	if err != nil {
+		addKeystoreError(&resp.Diagnostics, "read", err, data.errorPaths())
+		return
+	}
+	if !found {
+		tflog.Warn(ctx, "keystore entry was removed outside of Terraform, planning to add it again", map[string]interface{}{
+			"keystore_path": data.KeystorePath.ValueString(),
+			"alias":         data.Alias.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KeystoreEntryResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data KeystoreEntryResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := data.Write(ctx, r.runner); err != nil {
+		addKeystoreError(&resp.Diagnostics, "update", err, data.errorPaths())
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KeystoreEntryResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data KeystoreEntryResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := data.File(r.runner).DeleteEntry(ctx, data.Alias.ValueString()); err != nil {
+		addKeystoreError(&resp.Diagnostics, "delete", fmt.Errorf("error deleting keystore entry\n%w", err), data.errorPaths())
+		return
+	}
+}
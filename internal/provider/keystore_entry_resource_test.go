@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+const TestKeystoreEntryFullName = "jks_keystore_entry.test"
+
+func TestAccKeystoreEntryResource(t *testing.T) {
+	other := testTrustedEntry(t, "other", "Other Root CA")
+	keystore := testWriteFile(t, "keystore.jks", testEncodeJKS(t, StoreTypeJKS, []KeystoreEntry{other}, "changeit"))
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+	newRoot := testSelfSignedCertificatePEM(t, "New Root CA")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccKeystoreEntryConfig(keystore, root),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(TestKeystoreEntryFullName, "store_type", StoreTypeJKS),
+					resource.TestCheckResourceAttr(TestKeystoreEntryFullName, "entry_type", EntryTypeTrustedCertificate),
+					testCheckKeystoreFile(keystore, "other", "root"),
+				),
+			},
+			{
+				Config: testAccKeystoreEntryConfig(keystore, newRoot),
+				Check:  testCheckKeystoreFile(keystore, "other", "root"),
+			},
+			// An entry removed outside of Terraform is added again.
+			{
+				PreConfig: func() {
+					if err := os.WriteFile(keystore, testEncodeJKS(t, StoreTypeJKS, []KeystoreEntry{other}, "changeit"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccKeystoreEntryConfig(keystore, newRoot),
+				Check:  testCheckKeystoreFile(keystore, "other", "root"),
+			},
+		},
+	})
+
+	// Destroying the entry leaves the other entries.
+	if err := testCheckKeystoreFile(keystore, "other")(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccKeystoreEntryResourceStoreTypeCase(t *testing.T) {
+	keystore := testWriteFile(t, "keystore.jks", testEncodeJKS(t, StoreTypeJKS, nil, "changeit"))
+
+	// The store type is accepted ignoring case, and kept as configured so
+	// that the plan after the apply is empty.
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "jks_keystore_entry" "test" {
+  keystore_path   = %q
+  password        = "changeit"
+  store_type      = "jks"
+  alias           = "root"
+  certificate_pem = %q
+}
+`, keystore, testSelfSignedCertificatePEM(t, "Root CA")),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(TestKeystoreEntryFullName, "store_type", "jks"),
+					testCheckKeystoreFile(keystore, "root"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccKeystoreEntryResourceWithoutPassword(t *testing.T) {
+	data, err := encodePKCS12([]KeystoreEntry{testTrustedEntry(t, "other", "Other Root CA")}, "", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	truststore := testWriteFile(t, "truststore.p12", data)
+
+	// A CA is added to a password-less truststore, which stays password-less.
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "jks_keystore_entry" "test" {
+  keystore_path   = %q
+  alias           = "root"
+  certificate_pem = %q
+}
+`, truststore, testSelfSignedCertificatePEM(t, "Root CA")),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(TestKeystoreEntryFullName, "store_type", StoreTypePKCS12),
+					func(_ *terraform.State) error {
+						ks, err := KeystoreFile{Path: truststore}.Read(context.Background())
+						if err != nil {
+							return err
+						}
+						if fmt.Sprint(ks.Aliases()) != "[other root]" {
+							return fmt.Errorf("expected aliases [other root], got %v", ks.Aliases())
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+resource "jks_keystore_entry" "test" {
+  keystore_path   = %q
+  alias           = "server"
+  certificate_pem = %q
+  private_key_pem = "key"
+}
+`, truststore, testSelfSignedCertificatePEM(t, "Server")),
+				ExpectError: regexp.MustCompile(`Attribute "password" must be specified when "private_key_pem" is\s+specified`),
+			},
+		},
+	})
+}
+
+func testAccKeystoreEntryConfig(keystore, certificate string) string {
+	return fmt.Sprintf(`
+resource "jks_keystore_entry" "test" {
+  keystore_path   = %q
+  password        = "changeit"
+  alias           = "root"
+  certificate_pem = %q
+}
+`, keystore, certificate)
+}
+
+// testCheckKeystoreFile checks that the keystore file holds the aliases.
+func testCheckKeystoreFile(keystore string, aliases ...string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		ks, err := KeystoreFile{Path: keystore, Password: "changeit"}.Read(context.Background())
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(ks.Aliases()) != fmt.Sprint(aliases) {
+			return fmt.Errorf("expected aliases %v, got %v", aliases, ks.Aliases())
+		}
+		return nil
+	}
+}
+
+func TestKeystoreEntryResourceModelExpectedEntry(t *testing.T) {
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+	certs, err := ParseCertificatesPEM(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := KeystoreEntryResourceModel{
+		CertificatePem: types.StringValue(root),
+		PrivateKeyPem:  types.StringNull(),
+	}
+	entryType, fingerprint := data.ExpectedEntry()
+	if entryType.ValueString() != EntryTypeTrustedCertificate || fingerprint.ValueString() != SHA256Hex(certs[0].Raw) {
+		t.Errorf("unexpected entry %s %s", entryType, fingerprint)
+	}
+
+	data.PrivateKeyPem = types.StringValue("key")
+	if entryType, _ := data.ExpectedEntry(); entryType.ValueString() != EntryTypePrivateKey {
+		t.Errorf("expected a private key entry, got %s", entryType)
+	}
+
+	data.PrivateKeyPem = types.StringUnknown()
+	data.CertificatePem = types.StringUnknown()
+	if entryType, fingerprint := data.ExpectedEntry(); !entryType.IsUnknown() || !fingerprint.IsUnknown() {
+		t.Errorf("expected unknown values, got %s %s", entryType, fingerprint)
+	}
+}
+
+func TestKeystoreEntryResourceModelRefresh(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	key, chain := testKeyEntryPEM(server)
+	f := testKeystoreFile(t, fake, StoreTypeJKS, testTrustedEntry(t, "other", "Other Root CA"))
+
+	data := KeystoreEntryResourceModel{
+		KeystorePath:   types.StringValue(f.Path),
+		Password:       types.StringValue(f.Password),
+		StoreType:      types.StringUnknown(),
+		Alias:          types.StringValue("server"),
+		CertificatePem: types.StringValue(chain),
+		PrivateKeyPem:  types.StringValue(key),
+	}
+	if err := data.Write(ctx, fake); err != nil {
+		t.Fatal(err)
+	}
+	if data.StoreType.ValueString() != StoreTypeJKS || data.EntryType.ValueString() != EntryTypePrivateKey ||
+		data.FingerprintSha256.ValueString() != SHA256Hex(server.Certificates[0].Raw) {
+		t.Errorf("unexpected entry %s %s %s", data.StoreType, data.EntryType, data.FingerprintSha256)
+	}
+
+	// A store type configured in lower case is kept, so that it does not
+	// differ from the configuration.
+	data.StoreType = types.StringValue("jks")
+	if found, err := data.Refresh(ctx); err != nil || !found || data.StoreType.ValueString() != "jks" {
+		t.Errorf("expected the configured store type to be kept, got %s, %v", data.StoreType, err)
+	}
+
+	// An entry replaced outside of Terraform no longer matches the plan.
+	if err := f.PutEntry(ctx, testTrustedEntry(t, "server", "Server")); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := data.Refresh(ctx); err != nil || !found {
+		t.Fatalf("expected the entry to be found, got %v", err)
+	}
+	if data.EntryType.ValueString() != EntryTypeTrustedCertificate {
+		t.Errorf("expected the replaced entry to be recorded, got %s", data.EntryType)
+	}
+
+	if err := f.DeleteEntry(ctx, "server"); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := data.Refresh(ctx); err != nil || found {
+		t.Errorf("expected the removed entry not to be found, got %v", err)
+	}
+
+	data.Password = types.StringValue("wrong-password")
+	if _, err := data.Refresh(ctx); err == nil {
+		t.Error("expected an incorrect password to be reported")
+	}
+}
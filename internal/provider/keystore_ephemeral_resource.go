@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ ephemeral.EphemeralResource = &KeystoreEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithConfigure = &KeystoreEphemeralResource{}
+
+func NewKeystoreEphemeralResource() ephemeral.EphemeralResource {
+	return &KeystoreEphemeralResource{}
+}
+
+// KeystoreEphemeralResource defines the ephemeral resource implementation.
+type KeystoreEphemeralResource struct {
+	runner KeytoolRunner
+}
+
+// KeystoreEphemeralResourceModel describes the ephemeral resource data model.
+type KeystoreEphemeralResourceModel struct {
+	Password       types.String `tfsdk:"password"`
+	Subject        types.Object `tfsdk:"subject"`
+	File           types.String `tfsdk:"file"`
+	CertificatePEM types.String `tfsdk:"certificate_pem"`
+}
+
+func (r KeystoreEphemeralResourceModel) ToKeystoreModel() KeystoreModel {
+	return KeystoreModel{
+		Password:          r.Password.ValueString(),
+		DistinguishedName: SubjectDistinguishedName(r.Subject),
+	}
+}
+
+func (r *KeystoreEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_keystore"
+}
+
+func (r *KeystoreEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		Description: `
+        Generates a base64 encoded PKCS12 keystore valid for 25 years using the keytool utility, like the jks_keystore resource, without persisting it in the Terraform plan or state.
+        A new keystore is generated on every Terraform run. Requires Terraform 1.10 or later.
+        The machine running Terraform needs to have the keytool utility installed.
+        `,
+
+		Attributes: map[string]schema.Attribute{
+			"password": schema.StringAttribute{
+				Description: "Password for the keystore and the single key in the keystore",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"subject": schema.SingleNestedAttribute{
+				Description: "Distinguished name of the self-signed certificate",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"common_name": schema.StringAttribute{
+						Description: "Common Name (CN)",
+						Optional:    true,
+					},
+					"organization": schema.StringAttribute{
+						Description: "Organization (O)",
+						Optional:    true,
+					},
+					"organizational_unit": schema.StringAttribute{
+						Description: "Organizational Unit (OU)",
+						Optional:    true,
+					},
+					"locality": schema.StringAttribute{
+						Description: "Locality (L)",
+						Optional:    true,
+					},
+					"state": schema.StringAttribute{
+						Description: "State (S)",
+						Optional:    true,
+					},
+					"country": schema.StringAttribute{
+						Description: "Country (C)",
+						Optional:    true,
+					},
+				},
+			},
+			"file": schema.StringAttribute{
+				Description: "Base64 encoded keystore file",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"certificate_pem": schema.StringAttribute{
+				Description: "Self-signed certificate of the key in the keystore, in PEM format",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *KeystoreEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	runner, diags := keytoolRunnerFromProviderData(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.runner = runner
+}
+
+func (r *KeystoreEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data KeystoreEphemeralResourceModel
+
+	// Read Terraform config data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	model := data.ToKeystoreModel()
+	model.Runner = r.runner
+	errorPaths := keystoreErrorPaths{ErrIncorrectPassword: path.Root("password")}
+
+	b64File, err := model.CreateKeystoreBase64(ctx)
+	if err != nil {
+		addKeystoreError(&resp.Diagnostics, "open", err, errorPaths)
+		return
+	}
+	model.File = b64File
+
+	certificatePEM, err := model.CertificatePEM(ctx)
+	if err != nil {
+		addKeystoreError(&resp.Diagnostics, "open", err, errorPaths)
+		return
+	}
+
+	data.File = types.StringValue(b64File)
+	data.CertificatePEM = types.StringValue(certificatePEM)
+
+	tflog.Trace(ctx, "opened an ephemeral keystore")
+
+	// Save data into ephemeral result data
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
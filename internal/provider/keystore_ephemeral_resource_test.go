@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testAccProtoV6ProviderFactoriesWithEcho adds the echo provider, which
+// copies ephemeral values into state so that tests can inspect them.
+var testAccProtoV6ProviderFactoriesWithEcho = map[string]func() (tfprotov6.ProviderServer, error){
+	"jks":  providerserver.NewProtocol6WithError(New("test")()),
+	"echo": echoprovider.NewProviderServer(),
+}
+
+func TestAccKeystoreEphemeralResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithEcho,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+ephemeral "jks_keystore" "test" {
+    password = "MyPassword12345"
+    subject = {
+        common_name = "MyCommonName"
+    }
+}
+
+provider "echo" {
+    data = ephemeral.jks_keystore.test
+}
+
+resource "echo" "test" {}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("certificate_pem"), knownvalue.StringRegexp(regexp.MustCompile("^-----BEGIN CERTIFICATE-----"))),
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("file"), testKeystoreValue("MyPassword12345")),
+				},
+			},
+		},
+	})
+}
+
+// testKeystoreValue checks that a value is a base64 encoded PKCS12 keystore
+// that opens with password.
+func testKeystoreValue(password string) knownvalue.Check {
+	return knownvalue.StringFunc(func(value string) error {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("file is not base64 encoded: %s", err)
+		}
+		_, err = DecodeKeystore(decoded, StoreTypePKCS12, password)
+		return err
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// Kinds of keystore errors. Errors of the keystore layer wrap one of these
+// when the cause is known, so that errors.Is can tell them apart and the
+// diagnostic can point at the attribute to fix.
+var (
+	ErrKeytoolNotFound      = errors.New("keytool not found")
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	ErrCorruptKeystore      = errors.New("keystore is corrupt or not of the expected store type")
+	ErrAliasNotFound        = errors.New("alias not found in keystore")
+	ErrCertificateMismatch  = errors.New("certificate does not match the private key")
+)
+
+// KeystoreError is an error of a known kind.
+type KeystoreError struct {
+	Kind error
+	Err  error
+}
+
+func newKeystoreError(kind error, format string, args ...interface{}) *KeystoreError {
+	return &KeystoreError{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *KeystoreError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *KeystoreError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// KeytoolError is a failed keytool run. Kind is derived from the output of
+// keytool and is nil if the output is not recognized.
+type KeytoolError struct {
+	Kind   error
+	Err    error
+	Output string
+}
+
+func (e *KeytoolError) Error() string {
+	return fmt.Sprintf("Error: %s\nOutput: %s", e.Err, e.Output)
+}
+
+func (e *KeytoolError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// keytoolMessages maps messages keytool prints on failure to error kinds.
+// The messages are matched anywhere in the output, as keytool prefixes them
+// with the name of the Java exception.
+var keytoolMessages = []struct {
+	message *regexp.Regexp
+	kind    error
+}{
+	{regexp.MustCompile(`password was incorrect`), ErrIncorrectPassword},
+	{regexp.MustCompile(`final block not properly padded`), ErrIncorrectPassword},
+	{regexp.MustCompile(`Public keys in reply and keystore don't match`), ErrCertificateMismatch},
+	{regexp.MustCompile(`Certificate reply does not contain public key`), ErrCertificateMismatch},
+	{regexp.MustCompile(`Alias <.*> does not exist`), ErrAliasNotFound},
+	{regexp.MustCompile(`Invalid keystore format`), ErrCorruptKeystore},
+	{regexp.MustCompile(`Unrecognized keystore format`), ErrCorruptKeystore},
+	{regexp.MustCompile(`DerInputStream`), ErrCorruptKeystore},
+	{regexp.MustCompile(`NoSuchAlgorithmException`), ErrUnsupportedAlgorithm},
+	{regexp.MustCompile(`InvalidParameterException`), ErrUnsupportedAlgorithm},
+	{regexp.MustCompile(`InvalidAlgorithmParameterException`), ErrUnsupportedAlgorithm},
+	{regexp.MustCompile(`Cannot derive signature algorithm`), ErrUnsupportedAlgorithm},
+}
+
+// newKeytoolError classifies a failed keytool run by its error and output.
+func newKeytoolError(err error, output []byte) *KeytoolError {
+	e := &KeytoolError{Err: err, Output: strings.TrimSpace(string(output))}
+	if errors.Is(err, exec.ErrNotFound) {
+		e.Kind = ErrKeytoolNotFound
+		return e
+	}
+	for _, m := range keytoolMessages {
+		if m.message.MatchString(e.Output) {
+			e.Kind = m.kind
+			break
+		}
+	}
+	return e
+}
+
+// keystoreErrorSummaries are the diagnostic summaries of the error kinds,
+// with a hint on how to resolve them.
+var keystoreErrorSummaries = []struct {
+	kind    error
+	summary string
+	hint    string
+}{
+	{ErrKeytoolNotFound, "keytool not found", "Install a Java runtime on the machine running Terraform and make sure keytool is on the PATH."},
+	{ErrIncorrectPassword, "Incorrect keystore password", "Check that the password is the one the keystore was written with."},
+	{ErrUnsupportedAlgorithm, "Unsupported algorithm", "The algorithm is not supported by the installed keytool or by the provider. Choose another key_algorithm or signature_algorithm, or a keystore protected with supported algorithms."},
+	{ErrCorruptKeystore, "Invalid keystore", "The keystore could not be read. Check that it is complete, base64 encoded and of the configured store type."},
+	{ErrAliasNotFound, "Alias not found", "Check the alias against the aliases of the keystore."},
+	{ErrCertificateMismatch, "Certificate does not match the private key", "The certificate must be issued for the public key of the private key in the keystore."},
+}
+
+// keystoreErrorPaths maps error kinds to the attribute they are reported on.
+type keystoreErrorPaths map[error]path.Path
+
+// addKeystoreError reports err as a diagnostic of the named operation.
+// Errors of a known kind get an actionable summary and are reported on the
+// attribute paths maps the kind to.
+func addKeystoreError(diags *diag.Diagnostics, operation string, err error, paths keystoreErrorPaths) {
+	for _, s := range keystoreErrorSummaries {
+		if !errors.Is(err, s.kind) {
+			continue
+		}
+		detail := fmt.Sprintf("%s\n\n%s", s.hint, err)
+		if p, ok := paths[s.kind]; ok {
+			diags.AddAttributeError(p, s.summary, detail)
+		} else {
+			diags.AddError(s.summary, detail)
+		}
+		return
+	}
+	diags.AddError(fmt.Sprintf("Error during %s operation", operation), err.Error())
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestNewKeytoolError(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name   string
+		err    error
+		output string
+		kind   error
+	}{
+		{"not installed", &exec.Error{Name: "keytool", Err: exec.ErrNotFound}, "", ErrKeytoolNotFound},
+		{"incorrect password", exitErr, "keytool error: java.io.IOException: keystore password was incorrect", ErrIncorrectPassword},
+		{"incorrect key password", exitErr, "keytool error: javax.crypto.BadPaddingException: Given final block not properly padded", ErrIncorrectPassword},
+		{"alias not found", exitErr, "keytool error: java.lang.Exception: Alias <missing> does not exist", ErrAliasNotFound},
+		{"keystore file not found", exitErr, "keytool error: java.lang.Exception: Keystore file does not exist: /tmp/keystore.p12", nil},
+		{"corrupt", exitErr, "keytool error: java.io.IOException: Invalid keystore format", ErrCorruptKeystore},
+		{"not DER", exitErr, "keytool error: java.io.IOException: DerInputStream.getLength(): lengthTag=109, too big.", ErrCorruptKeystore},
+		{"unknown curve", exitErr, "keytool error: java.security.InvalidAlgorithmParameterException: Curve not supported: secp999r1", ErrUnsupportedAlgorithm},
+		{"key too small", exitErr, "keytool error: java.security.InvalidParameterException: RSA keys must be at least 512 bits long", ErrUnsupportedAlgorithm},
+		{"certificate reply", exitErr, "keytool error: java.lang.Exception: Public keys in reply and keystore don't match", ErrCertificateMismatch},
+		{"unrecognized", exitErr, "keytool error: java.lang.Exception: something else", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newKeytoolError(tt.err, []byte(tt.output+"\n"))
+			if err.Kind != tt.kind {
+				t.Errorf("expected kind %v, got %v", tt.kind, err.Kind)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("expected the error of the keytool run to be wrapped")
+			}
+			if tt.kind != nil && !errors.Is(fmt.Errorf("wrapped\n%w", err), tt.kind) {
+				t.Errorf("expected the wrapped error to be %v", tt.kind)
+			}
+		})
+	}
+}
+
+func TestDecodeKeystoreErrorKinds(t *testing.T) {
+	if _, err := DecodeKeystore([]byte("not a keystore"), StoreTypePKCS12, "changeit"); !errors.Is(err, ErrCorruptKeystore) {
+		t.Errorf("expected ErrCorruptKeystore, got %v", err)
+	}
+	if _, err := InspectKeystore(context.Background(), "not base64!", StoreTypePKCS12, "changeit"); !errors.Is(err, ErrCorruptKeystore) {
+		t.Errorf("expected ErrCorruptKeystore, got %v", err)
+	}
+	_, err := InspectKeystore(context.Background(), testFixtureBase64(t, "testdata/openssl-modern.p12"), StoreTypePKCS12, "wrong")
+	if !errors.Is(err, ErrIncorrectPassword) || errors.Is(err, ErrCorruptKeystore) {
+		t.Errorf("expected only ErrIncorrectPassword, got %v", err)
+	}
+}
+
+func TestAddKeystoreError(t *testing.T) {
+	paths := keystoreErrorPaths{ErrIncorrectPassword: path.Root("password")}
+
+	var diags diag.Diagnostics
+	addKeystoreError(&diags, "create", fmt.Errorf("error changing password\n%w", ErrIncorrectPassword), paths)
+	if len(diags) != 1 || diags[0].Summary() != "Incorrect keystore password" {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d, ok := diags[0].(diag.DiagnosticWithPath); !ok || !d.Path().Equal(path.Root("password")) {
+		t.Errorf("expected the diagnostic to be reported on password, got %v", diags[0])
+	}
+
+	diags = nil
+	addKeystoreError(&diags, "create", newKeytoolError(&exec.Error{Name: "keytool", Err: exec.ErrNotFound}, nil), paths)
+	if len(diags) != 1 || diags[0].Summary() != "keytool not found" {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if _, ok := diags[0].(diag.DiagnosticWithPath); ok {
+		t.Error("expected a diagnostic without attribute path")
+	}
+
+	diags = nil
+	addKeystoreError(&diags, "update", errors.New("disk full"), paths)
+	if len(diags) != 1 || diags[0].Summary() != "Error during update operation" || diags[0].Detail() != "disk full" {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// LocalFile describes where and how a keystore is written to disk.
+type LocalFile struct {
+	Path string
+	// Permission is an octal file mode such as "0600".
+	Permission string
+	// Owner and Group are user and group names or numeric ids. The file
+	// keeps the owner of the Terraform process when they are empty.
+	Owner string
+	Group string
+}
+
+// Write replaces the file atomically: the content is written and synced to
+// a temporary file in the same directory, which is then renamed over Path,
+// so readers never observe a partially written keystore.
+func (f LocalFile) Write(data []byte) error {
+	mode, err := ParseFilePermission(f.Permission)
+	if err != nil {
+		return err
+	}
+	uid, gid, err := lookupOwner(f.Owner, f.Group)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.Path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file next to %s\nError: %s", f.Path, err)
+	}
+	tmpName := tmp.Name()
+	// Removing the temporary file fails harmlessly once it has been renamed.
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing %s\nError: %s", tmpName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing %s\nError: %s", tmpName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing %s\nError: %s", tmpName, err)
+	}
+
+	// CreateTemp honors neither the umask nor the requested mode, so set it
+	// explicitly before the file becomes visible under its final name.
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return fmt.Errorf("error setting permission %s on %s\nError: %s", f.Permission, tmpName, err)
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(tmpName, uid, gid); err != nil {
+			return fmt.Errorf("error setting owner of %s\nError: %s", tmpName, err)
+		}
+	}
+
+	if err := os.Rename(tmpName, f.Path); err != nil {
+		return fmt.Errorf("error replacing %s\nError: %s", f.Path, err)
+	}
+	return nil
+}
+
+// SHA256 returns the hex encoded SHA-256 digest of the file, or an empty
+// string if the file does not exist.
+func (f LocalFile) SHA256() (string, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading %s\nError: %s", f.Path, err)
+	}
+	return SHA256Hex(data), nil
+}
+
+// Remove deletes the file. A file that is already gone is not an error.
+func (f LocalFile) Remove() error {
+	if err := os.Remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing %s\nError: %s", f.Path, err)
+	}
+	return nil
+}
+
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseFilePermission parses an octal file mode such as "0600".
+func ParseFilePermission(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("file permission must be an octal mode between 0000 and 0777, got %q", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// lookupOwner resolves user and group names or ids for os.Chown. Empty
+// values resolve to -1, which leaves the id unchanged.
+func lookupOwner(owner, group string) (int, int, error) {
+	uid, gid := -1, -1
+	if owner != "" {
+		id := owner
+		if _, err := strconv.Atoi(owner); err != nil {
+			u, err := user.Lookup(owner)
+			if err != nil {
+				return 0, 0, fmt.Errorf("error looking up file owner %q\nError: %s", owner, err)
+			}
+			id = u.Uid
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return 0, 0, fmt.Errorf("file owner %q does not have a numeric user id", owner)
+		}
+		uid = n
+	}
+	if group != "" {
+		id := group
+		if _, err := strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return 0, 0, fmt.Errorf("error looking up file group %q\nError: %s", group, err)
+			}
+			id = g.Gid
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return 0, 0, fmt.Errorf("file group %q does not have a numeric group id", group)
+		}
+		gid = n
+	}
+	return uid, gid, nil
+}
@@ -0,0 +1,257 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+// KeystoreFile is a keystore file on disk whose entries are edited one at a
+// time, keeping the entries managed by other tools. Each edit reads the
+// file, changes it with keytool and replaces it atomically while holding a
+// lock, so that concurrent edits of the same file do not lose each other's
+// changes.
+type KeystoreFile struct {
+	Path string
+	// StoreType is detected from the content of the file when empty. A file
+	// created by an edit is PKCS12 unless set.
+	StoreType string
+	// Password protects the file. Without one, the file is a PKCS12
+	// truststore with neither an integrity MAC nor encrypted certificates,
+	// which keytool cannot write, so it is edited without keytool.
+	Password string
+	// Runner runs keytool. The keytool executable is run when it is nil.
+	Runner KeytoolRunner
+}
+
+// NewKeystoreFileEntry builds the entry alias from PEM input: a trusted
+// certificate entry for a single certificate, or a private key entry for a
+// private key and its certificate chain, leaf first.
+func NewKeystoreFileEntry(alias, certificatePEM, privateKeyPEM string) (KeystoreEntry, error) {
+	certs, err := ParseCertificatesPEM(certificatePEM)
+	if err != nil {
+		return KeystoreEntry{}, fmt.Errorf("error parsing certificate\nError: %s", err)
+	}
+	if len(certs) == 0 {
+		return KeystoreEntry{}, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	if privateKeyPEM == "" {
+		if len(certs) != 1 {
+			return KeystoreEntry{}, fmt.Errorf("a trusted certificate entry holds exactly one certificate, got %d", len(certs))
+		}
+		return KeystoreEntry{Alias: alias, Type: EntryTypeTrustedCertificate, Certificates: certs}, nil
+	}
+
+	key, public, err := ParsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return KeystoreEntry{}, err
+	}
+	if !PublicKeysEqual(public, certs[0].PublicKey) {
+		return KeystoreEntry{}, newKeystoreError(ErrCertificateMismatch, "the certificate for %q does not match the private key", certs[0].Subject)
+	}
+	return KeystoreEntry{Alias: alias, Type: EntryTypePrivateKey, Key: key, Certificates: certs}, nil
+}
+
+// Read decodes the keystore file. Edits replace the file atomically, so it
+// is read without taking the lock. A missing file is an error wrapping
+// fs.ErrNotExist.
+func (f KeystoreFile) Read(ctx context.Context) (*DecodedKeystore, error) {
+	ctx = keystoreLogContext(ctx, f.Password)
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s\nError: %w", f.Path, err)
+	}
+	ks, err := decodeKeystore(ctx, data, f.StoreType, f.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s\nError: %w", f.Path, err)
+	}
+	return ks, nil
+}
+
+// PutEntry adds entry to the keystore file, replacing an entry of the same
+// alias. The file is created if it does not exist.
+func (f KeystoreFile) PutEntry(ctx context.Context, entry KeystoreEntry) error {
+	ctx = keystoreLogContext(ctx, f.Password)
+
+	return f.edit(ctx, func(m KeystoreModel, ws *workspace, ks *DecodedKeystore, fileName string) (bool, error) {
+		if f.Password == "" {
+			entries := slices.DeleteFunc(slices.Clone(ks.Entries), func(e KeystoreEntry) bool {
+				return strings.EqualFold(e.Alias, entry.Alias)
+			})
+			return true, f.encodeWithoutPassword(ws, ks, append(entries, entry))
+		}
+
+		if existing, ok := ks.Entry(entry.Alias); ok {
+			if err := f.deleteEntry(ctx, m, ks, fileName, existing.Alias); err != nil {
+				return false, err
+			}
+		}
+
+		if entry.Type == EntryTypeTrustedCertificate {
+			certificate, err := ws.write("certificate.pem", []byte(EncodeCertificatesPEM(entry.Certificates)))
+			if err != nil {
+				return false, err
+			}
+			_, err = m.runKeytool(ctx,
+				"-importcert",
+				"-noprompt",
+				"-alias", entry.Alias,
+				"-file", certificate,
+				"-keystore", fileName,
+				"-storetype", ks.StoreType,
+				"-storepass", f.Password,
+			)
+			if err != nil {
+				return false, fmt.Errorf("error adding entry %q to %s\n%w", entry.Alias, f.Path, err)
+			}
+			return true, nil
+		}
+
+		// keytool cannot import a PEM private key, so go through a PKCS12
+		// keystore holding the entry alone.
+		data, err := encodePKCS12([]KeystoreEntry{entry}, f.Password, PKCS12Protection{})
+		if err != nil {
+			return false, fmt.Errorf("error encoding entry %q\nError: %s", entry.Alias, err)
+		}
+		src, err := ws.write(FILENAME2, data)
+		if err != nil {
+			return false, err
+		}
+		err = m.importKeystore(ctx, src, fileName, importKeystoreOptions{
+			SrcStoreType:  StoreTypePKCS12,
+			SrcPassword:   f.Password,
+			DestStoreType: ks.StoreType,
+			DestPassword:  f.Password,
+			Aliases:       []string{entry.Alias},
+		})
+		if err != nil {
+			return false, fmt.Errorf("error adding entry %q to %s\n%w", entry.Alias, f.Path, err)
+		}
+		return true, nil
+	})
+}
+
+// DeleteEntry removes the entry alias from the keystore file. A missing
+// file or entry is not an error.
+func (f KeystoreFile) DeleteEntry(ctx context.Context, alias string) error {
+	ctx = keystoreLogContext(ctx, f.Password)
+
+	return f.edit(ctx, func(m KeystoreModel, ws *workspace, ks *DecodedKeystore, fileName string) (bool, error) {
+		existing, ok := ks.Entry(alias)
+		if !ok {
+			return false, nil
+		}
+		if f.Password == "" {
+			entries := slices.DeleteFunc(slices.Clone(ks.Entries), func(e KeystoreEntry) bool {
+				return e.Alias == existing.Alias
+			})
+			return true, f.encodeWithoutPassword(ws, ks, entries)
+		}
+		return true, f.deleteEntry(ctx, m, ks, fileName, existing.Alias)
+	})
+}
+
+func (f KeystoreFile) deleteEntry(ctx context.Context, m KeystoreModel, ks *DecodedKeystore, fileName, alias string) error {
+	_, err := m.runKeytool(ctx,
+		"-delete",
+		"-alias", alias,
+		"-keystore", fileName,
+		"-storetype", ks.StoreType,
+		"-storepass", f.Password,
+	)
+	if err != nil {
+		return fmt.Errorf("error removing entry %q from %s\n%w", alias, f.Path, err)
+	}
+	return nil
+}
+
+// encodeWithoutPassword writes entries as the edited copy of a password-less
+// keystore file, which only PKCS12 supports, and only for trusted
+// certificates.
+func (f KeystoreFile) encodeWithoutPassword(ws *workspace, ks *DecodedKeystore, entries []KeystoreEntry) error {
+	if ks.StoreType != StoreTypePKCS12 {
+		return fmt.Errorf("a %s keystore requires a password", ks.StoreType)
+	}
+	data, err := encodePKCS12(entries, "", PKCS12Protection{})
+	if err != nil {
+		return fmt.Errorf("error encoding %s\nError: %s", f.Path, err)
+	}
+	_, err = ws.write(FILENAME, data)
+	return err
+}
+
+// lockPath is the lock file serializing the edits of the keystore file.
+// The keystore itself cannot be locked, as every edit replaces it. The lock
+// file is never removed: another process may be waiting on it, and a new
+// lock file at the same path would not exclude that process.
+func (f KeystoreFile) lockPath() string {
+	return f.Path + ".lock"
+}
+
+// edit runs a read-modify-write of the keystore file under its lock. The
+// current content is decoded into ks and copied to fileName, which the edit
+// changes with keytool. When the edit reports a change, the file is
+// replaced by the edited copy, keeping its permission and owner.
+func (f KeystoreFile) edit(ctx context.Context, edit func(m KeystoreModel, ws *workspace, ks *DecodedKeystore, fileName string) (bool, error)) error {
+	unlock, err := lockFile(ctx, f.lockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dest := LocalFile{Path: f.Path, Permission: "0600"}
+	ks := &DecodedKeystore{StoreType: strings.ToUpper(cmp.Or(f.StoreType, StoreTypePKCS12))}
+
+	data, err := os.ReadFile(f.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		data = nil
+	case err != nil:
+		return fmt.Errorf("error reading %s\nError: %s", f.Path, err)
+	default:
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return fmt.Errorf("error reading %s\nError: %s", f.Path, err)
+		}
+		dest.Permission = fmt.Sprintf("%04o", info.Mode().Perm())
+		dest.Owner, dest.Group = fileOwnership(info)
+
+		if ks, err = decodeKeystore(ctx, data, f.StoreType, f.Password); err != nil {
+			return fmt.Errorf("error reading %s\nError: %w", f.Path, err)
+		}
+	}
+
+	ws, err := newWorkspace()
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	fileName := ws.path(FILENAME)
+	if data != nil {
+		if _, err := ws.write(FILENAME, data); err != nil {
+			return err
+		}
+	}
+
+	changed, err := edit(KeystoreModel{Password: f.Password, Runner: f.Runner}, ws, ks, fileName)
+	if err != nil || !changed {
+		return err
+	}
+
+	edited, err := ws.read(FILENAME)
+	if err != nil {
+		return err
+	}
+	return dest.Write(edited)
+}
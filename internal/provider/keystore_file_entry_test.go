@@ -0,0 +1,337 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// testKeyEntryPEM returns the private key and certificate chain of a
+// private key entry as PEM.
+func testKeyEntryPEM(entry *KeystoreEntry) (string, string) {
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: entry.Key}))
+	return key, EncodeCertificatesPEM(entry.Certificates)
+}
+
+// testKeystoreFile writes a keystore of storeType holding entries and
+// returns it as a KeystoreFile edited by fake.
+func testKeystoreFile(t *testing.T, fake *fakeKeytool, storeType string, entries ...KeystoreEntry) KeystoreFile {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "keystore")
+	if err := fake.writeKeystore(nil, p, storeType, "changeit", entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(p, 0640); err != nil {
+		t.Fatal(err)
+	}
+	return KeystoreFile{Path: p, Password: "changeit", Runner: fake}
+}
+
+func TestNewKeystoreFileEntry(t *testing.T) {
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	key, chain := testKeyEntryPEM(server)
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+
+	entry, err := NewKeystoreFileEntry("root", root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Type != EntryTypeTrustedCertificate || len(entry.Certificates) != 1 {
+		t.Errorf("expected a trusted certificate entry, got %+v", entry)
+	}
+
+	entry, err = NewKeystoreFileEntry("server", chain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Type != EntryTypePrivateKey || len(entry.Certificates) != len(server.Certificates) {
+		t.Errorf("expected a private key entry with its chain, got %+v", entry)
+	}
+
+	if _, err := NewKeystoreFileEntry("chain", root+root, ""); err == nil {
+		t.Error("expected a chain without a private key to be rejected")
+	}
+	if _, err := NewKeystoreFileEntry("server", root, key); !errors.Is(err, ErrCertificateMismatch) {
+		t.Errorf("expected ErrCertificateMismatch, got %v", err)
+	}
+	if _, err := NewKeystoreFileEntry("empty", "", ""); err == nil {
+		t.Error("expected a missing certificate to be rejected")
+	}
+}
+
+func TestKeystoreFilePutEntry(t *testing.T) {
+	ctx := context.Background()
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	key, chain := testKeyEntryPEM(server)
+	other := testTrustedEntry(t, "other", "Other Root CA")
+
+	for _, storeType := range []string{StoreTypePKCS12, StoreTypeJKS} {
+		t.Run(storeType, func(t *testing.T) {
+			fake := newFakeKeytool(t)
+			f := testKeystoreFile(t, fake, storeType, other)
+
+			root, err := NewKeystoreFileEntry("root", testSelfSignedCertificatePEM(t, "Root CA"), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := f.PutEntry(ctx, root); err != nil {
+				t.Fatal(err)
+			}
+			// Replacing an entry ignores the case of its alias.
+			replaced, err := NewKeystoreFileEntry("ROOT", testSelfSignedCertificatePEM(t, "New Root CA"), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := f.PutEntry(ctx, replaced); err != nil {
+				t.Fatal(err)
+			}
+			keyEntry, err := NewKeystoreFileEntry("server", chain, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := f.PutEntry(ctx, keyEntry); err != nil {
+				t.Fatal(err)
+			}
+
+			ks, err := f.Read(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ks.StoreType != storeType {
+				t.Errorf("expected the store type to be kept, got %s", ks.StoreType)
+			}
+			if fmt.Sprint(ks.Aliases()) != "[ROOT other server]" {
+				t.Errorf("unexpected aliases %v", ks.Aliases())
+			}
+			if entry, _ := ks.Entry("root"); !entry.Certificates[0].Equal(replaced.Certificates[0]) {
+				t.Error("expected the entry to be replaced")
+			}
+			if entry, _ := ks.Entry("server"); entry.Type != EntryTypePrivateKey || string(entry.Key) != string(server.Key) {
+				t.Errorf("expected the private key entry, got %+v", entry)
+			}
+			if info, err := os.Stat(f.Path); err != nil || info.Mode().Perm() != 0640 {
+				t.Errorf("expected the permission of the file to be kept, got %v", info.Mode())
+			}
+		})
+	}
+}
+
+func TestKeystoreFileDeleteEntry(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	f := testKeystoreFile(t, fake, StoreTypeJKS, testTrustedEntry(t, "root", "Root CA"), testTrustedEntry(t, "other", "Other Root CA"))
+
+	if err := f.DeleteEntry(ctx, "Root"); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := f.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ks.Aliases()) != "[other]" {
+		t.Errorf("expected only the other entry to be left, got %v", ks.Aliases())
+	}
+
+	// Deleting a missing entry, or from a missing file, changes nothing.
+	if err := f.DeleteEntry(ctx, "root"); err != nil {
+		t.Fatal(err)
+	}
+	f.Path = filepath.Join(t.TempDir(), "missing")
+	if err := f.DeleteEntry(ctx, "root"); err != nil {
+		t.Fatal(err)
+	}
+	if commands := fake.commands(); !slices.Equal(commands, []string{"-delete"}) {
+		t.Errorf("expected a single keytool run, got %v", commands)
+	}
+}
+
+func TestKeystoreFileCreate(t *testing.T) {
+	ctx := context.Background()
+	f := KeystoreFile{Path: filepath.Join(t.TempDir(), "truststore.p12"), Password: "changeit", Runner: newFakeKeytool(t)}
+
+	if _, err := f.Read(ctx); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing file to be reported, got %v", err)
+	}
+	if err := f.PutEntry(ctx, testTrustedEntry(t, "root", "Root CA")); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := f.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.StoreType != StoreTypePKCS12 || fmt.Sprint(ks.Aliases()) != "[root]" {
+		t.Errorf("expected a PKCS12 keystore holding root, got %s %v", ks.StoreType, ks.Aliases())
+	}
+	if info, err := os.Stat(f.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected a new file to be private, got %v", info.Mode())
+	}
+}
+
+func TestKeystoreFileConcurrentEdits(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	f := testKeystoreFile(t, fake, StoreTypeJKS)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		entry := testTrustedEntry(t, fmt.Sprintf("root-%d", i), "Root CA")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- f.PutEntry(ctx, entry)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ks, err := f.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.Entries) != cap(errs) {
+		t.Errorf("expected every concurrent edit to be kept, got %v", ks.Aliases())
+	}
+}
+
+func TestKeystoreFileConcurrentCreateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	f := KeystoreFile{Path: filepath.Join(t.TempDir(), "truststore.jks"), Password: "changeit", StoreType: StoreTypeJKS, Runner: fake}
+
+	// Deleting from the missing keystore while it is created keeps the lock
+	// file, so that no edit runs unserialized and every entry is kept.
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs)/2; i++ {
+		entry := testTrustedEntry(t, fmt.Sprintf("root-%d", i), "Root CA")
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- f.PutEntry(ctx, entry)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- f.DeleteEntry(ctx, "missing")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ks, err := f.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.Entries) != cap(errs)/2 {
+		t.Errorf("expected every concurrent edit to be kept, got %v", ks.Aliases())
+	}
+	if _, err := os.Stat(f.lockPath()); err != nil {
+		t.Errorf("expected the lock file to be left in place, got %v", err)
+	}
+}
+
+func TestKeystoreFileIncorrectPassword(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	f := testKeystoreFile(t, fake, StoreTypePKCS12, testTrustedEntry(t, "root", "Root CA"))
+	before, err := os.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Password = "wrong-password"
+	if err := f.PutEntry(ctx, testTrustedEntry(t, "other", "Other Root CA")); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("expected ErrIncorrectPassword, got %v", err)
+	}
+	if _, err := f.Read(ctx); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("expected ErrIncorrectPassword, got %v", err)
+	}
+	if after, err := os.ReadFile(f.Path); err != nil || string(after) != string(before) {
+		t.Error("expected the file to be left unchanged")
+	}
+	if len(fake.runs) != 0 {
+		t.Errorf("expected keytool not to run, got %v", fake.commands())
+	}
+}
+
+func TestKeystoreFileWithoutPassword(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	data, err := encodePKCS12([]KeystoreEntry{testTrustedEntry(t, "other", "Other Root CA")}, "", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := KeystoreFile{Path: testWriteFile(t, "truststore.p12", data), Runner: fake}
+
+	// Entries of a password-less truststore are edited without keytool,
+	// keeping the truststore password-less.
+	if err := f.PutEntry(ctx, testTrustedEntry(t, "root", "Root CA")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.PutEntry(ctx, testTrustedEntry(t, "ROOT", "New Root CA")); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := f.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ks.Aliases()) != "[ROOT other]" {
+		t.Errorf("unexpected aliases %v", ks.Aliases())
+	}
+	if entry, _ := ks.Entry("root"); entry.Certificates[0].Subject.CommonName != "New Root CA" {
+		t.Error("expected the entry to be replaced")
+	}
+
+	if err := f.DeleteEntry(ctx, "other"); err != nil {
+		t.Fatal(err)
+	}
+	edited, err := os.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protection, err := inspectPKCS12Protection(edited); err != nil || protection.MacAlgorithm != "" || protection.CertificateAlgorithm != "" {
+		t.Errorf("expected neither an integrity MAC nor encrypted certificates, got %+v, %v", protection, err)
+	}
+	if ks, err := f.Read(ctx); err != nil || fmt.Sprint(ks.Aliases()) != "[ROOT]" {
+		t.Errorf("expected only ROOT to be left, got %v, %v", ks, err)
+	}
+
+	// Without a password, a file is created as a password-less truststore.
+	created := KeystoreFile{Path: filepath.Join(t.TempDir(), "created.p12"), Runner: fake}
+	if err := created.PutEntry(ctx, testTrustedEntry(t, "root", "Root CA")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := created.Read(ctx); err != nil {
+		t.Error(err)
+	}
+
+	// A password-less truststore cannot hold private keys.
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	if err := f.PutEntry(ctx, *server); err == nil {
+		t.Error("expected an error for a private key entry")
+	}
+
+	if len(fake.runs) != 0 {
+		t.Errorf("expected keytool not to run, got %v", fake.commands())
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	f := LocalFile{Path: filepath.Join(dir, "keystore.p12"), Permission: "0640"}
+
+	if digest, err := f.SHA256(); err != nil || digest != "" {
+		t.Fatalf("expected empty digest for a missing file, got %q, %v", digest, err)
+	}
+
+	for _, content := range []string{"first", "second"} {
+		if err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("file contains %q, expected %q", data, content)
+		}
+		if digest, _ := f.SHA256(); digest != SHA256Hex([]byte(content)) {
+			t.Errorf("digest %s does not match the content", digest)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("file mode is %o, expected 0640", info.Mode().Perm())
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the keystore in %s, found %d files", dir, len(entries))
+	}
+
+	if err := f.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Remove(); err != nil {
+		t.Errorf("removing a missing file failed: %v", err)
+	}
+}
+
+func TestParseFilePermission(t *testing.T) {
+	for _, valid := range []string{"0600", "644", "0000", "0777"} {
+		if _, err := ParseFilePermission(valid); err != nil {
+			t.Errorf("%s: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "rw-------", "0800", "1777"} {
+		if _, err := ParseFilePermission(invalid); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+}
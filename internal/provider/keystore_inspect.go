@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// KeystoreEntryModel describes a keystore entry as the jks_keystore data
+// source and the decode_keystore function return it. Certificate details
+// are those of the leaf certificate, and null for entries without one.
+type KeystoreEntryModel struct {
+	Alias             types.String `tfsdk:"alias"`
+	Type              types.String `tfsdk:"type"`
+	Subject           types.String `tfsdk:"subject"`
+	Issuer            types.String `tfsdk:"issuer"`
+	SerialNumber      types.String `tfsdk:"serial_number"`
+	NotBefore         types.String `tfsdk:"not_before"`
+	NotAfter          types.String `tfsdk:"not_after"`
+	FingerprintSha256 types.String `tfsdk:"fingerprint_sha256"`
+	ChainLength       types.Int64  `tfsdk:"chain_length"`
+}
+
+var keystoreEntryAttrTypes = map[string]attr.Type{
+	"alias":              types.StringType,
+	"type":               types.StringType,
+	"subject":            types.StringType,
+	"issuer":             types.StringType,
+	"serial_number":      types.StringType,
+	"not_before":         types.StringType,
+	"not_after":          types.StringType,
+	"fingerprint_sha256": types.StringType,
+	"chain_length":       types.Int64Type,
+}
+
+// InspectKeystore decodes a base64 encoded keystore for inspection. The
+// store type is detected when storeType is empty.
+func InspectKeystore(ctx context.Context, b64File, storeType, password string) (*DecodedKeystore, error) {
+	decoded, err := base64.StdEncoding.DecodeString(b64File)
+	if err != nil {
+		return nil, newKeystoreError(ErrCorruptKeystore, "error decoding base64 keystore file\nError: %s", err)
+	}
+	return decodeKeystore(keystoreLogContext(ctx, password), decoded, storeType, password)
+}
+
+// detectBase64StoreType is DetectStoreType for a base64 encoded keystore.
+func detectBase64StoreType(b64File string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(b64File)
+	if err != nil {
+		return "", newKeystoreError(ErrCorruptKeystore, "error decoding base64 keystore file\nError: %s", err)
+	}
+	return DetectStoreType(decoded)
+}
+
+// decodeKeystore is DecodeKeystore, logged to the jks subsystem.
+func decodeKeystore(ctx context.Context, data []byte, storeType, password string) (*DecodedKeystore, error) {
+	start := time.Now()
+	ks, err := DecodeKeystore(data, storeType, password)
+	var aliases []string
+	if err == nil {
+		storeType = ks.StoreType
+		aliases = ks.Aliases()
+	}
+	logKeystoreOperation(ctx, "decode", storeType, aliases, start, err)
+	return ks, err
+}
+
+// Aliases returns the aliases of all entries, sorted.
+func (k *DecodedKeystore) Aliases() []string {
+	aliases := make([]string, 0, len(k.Entries))
+	for _, entry := range k.Entries {
+		aliases = append(aliases, entry.Alias)
+	}
+	return aliases
+}
+
+// EntryModels describes every entry of the keystore, sorted by alias.
+func (k *DecodedKeystore) EntryModels() []KeystoreEntryModel {
+	models := make([]KeystoreEntryModel, 0, len(k.Entries))
+	for _, entry := range k.Entries {
+		model := KeystoreEntryModel{
+			Alias:             types.StringValue(entry.Alias),
+			Type:              types.StringValue(entry.Type),
+			Subject:           types.StringNull(),
+			Issuer:            types.StringNull(),
+			SerialNumber:      types.StringNull(),
+			NotBefore:         types.StringNull(),
+			NotAfter:          types.StringNull(),
+			FingerprintSha256: types.StringNull(),
+			ChainLength:       types.Int64Value(int64(len(entry.Certificates))),
+		}
+		if len(entry.Certificates) > 0 {
+			cert := entry.Certificates[0]
+			model.Subject = types.StringValue(cert.Subject.String())
+			model.Issuer = types.StringValue(cert.Issuer.String())
+			model.SerialNumber = types.StringValue(cert.SerialNumber.Text(16))
+			model.NotBefore = types.StringValue(cert.NotBefore.UTC().Format(time.RFC3339))
+			model.NotAfter = types.StringValue(cert.NotAfter.UTC().Format(time.RFC3339))
+			model.FingerprintSha256 = types.StringValue(SHA256Hex(cert.Raw))
+		}
+		models = append(models, model)
+	}
+	return models
+}
+
+// VerificationModel is the result of the verify_* functions, for use in
+// the condition and error_message of a precondition or check.
+type VerificationModel struct {
+	Valid   types.Bool   `tfsdk:"valid"`
+	Message types.String `tfsdk:"message"`
+}
+
+var verificationAttrTypes = map[string]attr.Type{
+	"valid":   types.BoolType,
+	"message": types.StringType,
+}
+
+// NewVerificationModel reports err as an invalid result, or success.
+func NewVerificationModel(err error, success string) VerificationModel {
+	if err != nil {
+		return VerificationModel{Valid: types.BoolValue(false), Message: types.StringValue(err.Error())}
+	}
+	return VerificationModel{Valid: types.BoolValue(true), Message: types.StringValue(success)}
+}
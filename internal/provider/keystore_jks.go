@@ -0,0 +1,373 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// JKS and JCEKS decoding. Both are the proprietary formats of the Sun
+// provider: a big-endian stream of tagged entries followed by a SHA-1
+// integrity digest keyed with the store password. JKS protects private keys
+// with a SHA-1 keystream, JCEKS with PBEWithMD5AndTripleDES.
+
+const (
+	jksMagic   = 0xfeedfeed
+	jceksMagic = 0xcececece
+
+	jksTagPrivateKey  = 1
+	jksTagTrustedCert = 2
+	jksTagSecretKey   = 3
+)
+
+var (
+	oidJKSKeyProtector           = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+	oidPBEWithMD5AndTripleDESCBC = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 19, 1}
+)
+
+// jksReader reads the primitives of a Java DataInputStream, checking every
+// length against the remaining input before allocating.
+type jksReader struct {
+	data []byte
+	off  int
+}
+
+func (r *jksReader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.off {
+		return nil, errors.New("keystore is truncated")
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+func (r *jksReader) uint16() (uint16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (r *jksReader) uint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// utf reads a string written by DataOutputStream.writeUTF.
+func (r *jksReader) utf() (string, error) {
+	n, err := r.uint16()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.next(int(n))
+	if err != nil {
+		return "", err
+	}
+	return decodeModifiedUTF8(b)
+}
+
+// lengthPrefixed reads a 32-bit length followed by that many bytes.
+func (r *jksReader) lengthPrefixed() ([]byte, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint32(len(r.data)-r.off) {
+		return nil, errors.New("keystore is truncated")
+	}
+	return r.next(int(n))
+}
+
+func (r *jksReader) certificate(version uint32) (*x509.Certificate, error) {
+	if version == 2 {
+		certType, err := r.utf()
+		if err != nil {
+			return nil, err
+		}
+		if certType != "X.509" {
+			return nil, fmt.Errorf("unsupported certificate type %q", certType)
+		}
+	}
+	der, err := r.lengthPrefixed()
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// decodeJKS decodes a keystore of store type JKS or JCEKS.
+func decodeJKS(data []byte, storeType, password string) ([]KeystoreEntry, error) {
+	r := &jksReader{data: data}
+
+	expected := uint32(jksMagic)
+	if storeType == StoreTypeJCEKS {
+		expected = jceksMagic
+	}
+	magic, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if magic != expected {
+		return nil, fmt.Errorf("not a %s keystore", storeType)
+	}
+	version, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported keystore version %d", version)
+	}
+	count, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []KeystoreEntry
+	for i := uint32(0); i < count; i++ {
+		tag, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		alias, err := r.utf()
+		if err != nil {
+			return nil, err
+		}
+		// Creation date in milliseconds since the epoch.
+		if _, err := r.next(8); err != nil {
+			return nil, err
+		}
+
+		switch tag {
+		case jksTagPrivateKey:
+			encrypted, err := r.lengthPrefixed()
+			if err != nil {
+				return nil, err
+			}
+			n, err := r.uint32()
+			if err != nil {
+				return nil, err
+			}
+			// Every certificate takes at least its 4 byte length.
+			if n > uint32(len(data)-r.off)/4 {
+				return nil, errors.New("keystore is truncated")
+			}
+			chain := make([]*x509.Certificate, 0, n)
+			for j := uint32(0); j < n; j++ {
+				cert, err := r.certificate(version)
+				if err != nil {
+					return nil, fmt.Errorf("error reading certificate chain of %q: %w", alias, err)
+				}
+				chain = append(chain, cert)
+			}
+			entries = append(entries, KeystoreEntry{
+				Alias:        alias,
+				Type:         EntryTypePrivateKey,
+				Key:          encrypted,
+				Certificates: chain,
+			})
+		case jksTagTrustedCert:
+			cert, err := r.certificate(version)
+			if err != nil {
+				return nil, fmt.Errorf("error reading certificate %q: %w", alias, err)
+			}
+			entries = append(entries, KeystoreEntry{
+				Alias:        alias,
+				Type:         EntryTypeTrustedCertificate,
+				Certificates: []*x509.Certificate{cert},
+			})
+		case jksTagSecretKey:
+			// Secret keys are serialized Java objects, which cannot be
+			// skipped without a Java deserializer.
+			return nil, fmt.Errorf("entry %q is a %s, which is not supported", alias, EntryTypeSecretKey)
+		default:
+			return nil, fmt.Errorf("unknown keystore entry tag %d", tag)
+		}
+	}
+
+	// keytool -list without a password skips the integrity check, and so
+	// does an empty password here.
+	digest, err := r.next(sha1.Size)
+	if err != nil {
+		return nil, err
+	}
+	if r.off != len(data) {
+		return nil, errors.New("trailing data after the keystore digest")
+	}
+	if password != "" {
+		h := sha1.New()
+		h.Write(utf16BEPassword(password))
+		h.Write([]byte("Mighty Aphrodite"))
+		h.Write(data[:len(data)-sha1.Size])
+		if subtle.ConstantTimeCompare(h.Sum(nil), digest) != 1 {
+			return nil, ErrIncorrectPassword
+		}
+	}
+
+	for i := range entries {
+		if entries[i].Type != EntryTypePrivateKey {
+			continue
+		}
+		key, err := decryptJKSKey(entries[i].Key, password)
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Key = key
+	}
+	return entries, nil
+}
+
+// decryptJKSKey decrypts the EncryptedPrivateKeyInfo of a private key entry
+// into PKCS#8.
+func decryptJKSKey(der []byte, password string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if err := unmarshalDER(der, &info); err != nil {
+		return nil, fmt.Errorf("error reading encrypted private key: %w", err)
+	}
+
+	switch {
+	case info.Algorithm.Algorithm.Equal(oidJKSKeyProtector):
+		return jksKeyProtectorDecrypt(info.EncryptedData, password)
+	case info.Algorithm.Algorithm.Equal(oidPBEWithMD5AndTripleDESCBC):
+		var params pbeParams
+		if err := unmarshalDER(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("error reading PBE parameters: %w", err)
+		}
+		return pbeWithMD5AndTripleDESDecrypt(info.EncryptedData, password, params)
+	default:
+		return nil, newKeystoreError(ErrUnsupportedAlgorithm, "unsupported private key protection algorithm %s", info.Algorithm.Algorithm)
+	}
+}
+
+// jksKeyProtectorDecrypt implements sun.security.provider.KeyProtector: the
+// data is a 20 byte salt, the key XORed with a keystream of chained SHA-1
+// digests of the password and salt, and a SHA-1 check of password and key.
+func jksKeyProtectorDecrypt(data []byte, password string) ([]byte, error) {
+	if len(data) < 2*sha1.Size {
+		return nil, errors.New("protected private key is too short")
+	}
+	salt := data[:sha1.Size]
+	encrypted := data[sha1.Size : len(data)-sha1.Size]
+	check := data[len(data)-sha1.Size:]
+	passwd := utf16BEPassword(password)
+
+	key := make([]byte, len(encrypted))
+	digest := salt
+	for i := 0; i < len(encrypted); i += sha1.Size {
+		h := sha1.New()
+		h.Write(passwd)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(encrypted); j++ {
+			key[i+j] = encrypted[i+j] ^ digest[j]
+		}
+	}
+
+	h := sha1.New()
+	h.Write(passwd)
+	h.Write(key)
+	if subtle.ConstantTimeCompare(h.Sum(nil), check) != 1 {
+		return nil, ErrIncorrectPassword
+	}
+	return key, nil
+}
+
+// pbeWithMD5AndTripleDESDecrypt implements the proprietary PBE scheme of
+// com.sun.crypto.provider.KeyProtector used by JCEKS. Each half of the salt
+// is hashed with the password to derive 24 bytes of key and 8 bytes of IV.
+func pbeWithMD5AndTripleDESDecrypt(encrypted []byte, password string, params pbeParams) ([]byte, error) {
+	if len(params.Salt) != 8 {
+		return nil, errors.New("PBEWithMD5AndTripleDES salt must be 8 bytes")
+	}
+	if err := checkIterations(params.Iterations); err != nil {
+		return nil, fmt.Errorf("error reading PBEWithMD5AndTripleDES parameters: %w", err)
+	}
+
+	salt := bytes.Clone(params.Salt)
+	if bytes.Equal(salt[:4], salt[4:]) {
+		salt[0], salt[3] = salt[3], salt[0]
+		salt[1], salt[2] = salt[2], salt[1]
+	}
+	// The password is encoded as 7-bit ASCII, as javax.crypto.spec.PBEKeySpec
+	// does for this algorithm.
+	passwd := make([]byte, 0, len(password))
+	for _, c := range password {
+		passwd = append(passwd, byte(c&0x7f))
+	}
+
+	derived := make([]byte, 0, 2*md5.Size)
+	for i := 0; i < 2; i++ {
+		digest := salt[i*4 : i*4+4]
+		for j := 0; j < params.Iterations; j++ {
+			h := md5.New()
+			h.Write(digest)
+			h.Write(passwd)
+			digest = h.Sum(nil)
+		}
+		derived = append(derived, digest...)
+	}
+
+	block, err := des.NewTripleDESCipher(derived[:24])
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) == 0 || len(encrypted)%block.BlockSize() != 0 {
+		return nil, errors.New("encrypted data is not a multiple of the cipher block size")
+	}
+	decrypted := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, derived[24:]).CryptBlocks(decrypted, encrypted)
+
+	n := int(decrypted[len(decrypted)-1])
+	if n == 0 || n > block.BlockSize() || !bytes.Equal(decrypted[len(decrypted)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, ErrIncorrectPassword
+	}
+	return decrypted[:len(decrypted)-n], nil
+}
+
+// utf16BEPassword encodes the password as Java chars, without the
+// terminating zero that PKCS#12 adds.
+func utf16BEPassword(password string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(password)) {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return b
+}
+
+// decodeModifiedUTF8 decodes Java's modified UTF-8, which encodes NUL as two
+// bytes and characters outside the BMP as two 3-byte surrogates.
+func decodeModifiedUTF8(b []byte) (string, error) {
+	var chars []uint16
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			chars = append(chars, uint16(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(b) && b[i+1]&0xc0 == 0x80:
+			chars = append(chars, uint16(c&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(b) && b[i+1]&0xc0 == 0x80 && b[i+2]&0xc0 == 0x80:
+			chars = append(chars, uint16(c&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			return "", errors.New("malformed alias")
+		}
+	}
+	return string(utf16.Decode(chars)), nil
+}
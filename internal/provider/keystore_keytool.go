@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// KeytoolRunner runs keytool. The provider hands its runner to resources
+// as provider data, so that tests can replace keytool.
+type KeytoolRunner interface {
+	// Run runs keytool with args and returns its combined output. A failed
+	// run returns an error, which is an *exec.ExitError if keytool exited
+	// with a non-zero status.
+	Run(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// ExecKeytoolRunner runs the keytool executable found on the PATH.
+type ExecKeytoolRunner struct{}
+
+func (ExecKeytoolRunner) Run(ctx context.Context, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, "keytool", args...).CombinedOutput()
+}
+
+// keytoolRunnerFromProviderData returns the runner passed as provider data,
+// or the keytool executable when the provider has not been configured.
+func keytoolRunnerFromProviderData(providerData any) (KeytoolRunner, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if providerData == nil {
+		return ExecKeytoolRunner{}, diags
+	}
+	runner, ok := providerData.(KeytoolRunner)
+	if !ok {
+		diags.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected KeytoolRunner, got: %T. Please report this issue to the provider developers.", providerData),
+		)
+	}
+	return runner, diags
+}
+
+// keytoolExitCode returns the exit status of a keytool run, or -1 if
+// keytool did not run to completion.
+func keytoolExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// runKeytool runs keytool with the runner of the model and returns its
+// output. A failed run returns a *KeytoolError classified by the output.
+// Every run is logged to the jks subsystem, without the passwords on the
+// command line.
+func (m KeystoreModel) runKeytool(ctx context.Context, args ...string) ([]byte, error) {
+	runner := m.Runner
+	if runner == nil {
+		runner = ExecKeytoolRunner{}
+	}
+
+	ctx = tflog.SubsystemMaskLogStrings(ctx, logSubsystem, keytoolPasswords(args)...)
+	fields := keytoolLogFields(args)
+
+	start := time.Now()
+	out, err := runner.Run(ctx, args...)
+	fields[logFieldDuration] = time.Since(start).Milliseconds()
+	fields[logFieldExitCode] = keytoolExitCode(err)
+
+	if err != nil {
+		kerr := newKeytoolError(err, out)
+		fields[logFieldOutput] = kerr.Output
+		tflog.SubsystemDebug(ctx, logSubsystem, "keytool failed", fields)
+		return out, kerr
+	}
+	tflog.SubsystemDebug(ctx, logSubsystem, "keytool completed", fields)
+	return out, nil
+}
@@ -0,0 +1,366 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// errFakeKeytoolExit is the error of a failed fake keytool run.
+var errFakeKeytoolExit = errors.New("exit status 1")
+
+// fakeKeytool is a KeytoolRunner that records its runs and carries out the
+// keytool commands used by KeystoreModel with the Go keystore encoder, so
+// that tests do not need a JVM. Generated keys are always ECDSA P-256;
+// tests assert the requested algorithm on the arguments.
+type fakeKeytool struct {
+	t testing.TB
+	// mu serializes runs, which edits of a keystore file make concurrently.
+	mu sync.Mutex
+	// runs are the arguments of every run, in order.
+	runs [][]string
+	// failures make a command, such as "-genkeypair", fail with the error
+	// and output.
+	failures map[string]fakeKeytoolFailure
+	// ignoreProtection makes keytool ignore the keystore.pkcs12 security
+	// properties and write legacy keystores, as Java 8 before 8u301 does.
+	ignoreProtection bool
+}
+
+type fakeKeytoolFailure struct {
+	err    error
+	output string
+}
+
+func newFakeKeytool(t testing.TB) *fakeKeytool {
+	return &fakeKeytool{t: t, failures: map[string]fakeKeytoolFailure{}}
+}
+
+// fail makes command fail as keytool does, printing output.
+func (f *fakeKeytool) fail(command, output string) {
+	f.failures[command] = fakeKeytoolFailure{err: errFakeKeytoolExit, output: output + "\n"}
+}
+
+// missing makes every run fail as if keytool was not installed.
+func (f *fakeKeytool) missing() {
+	f.failures[""] = fakeKeytoolFailure{err: &exec.Error{Name: "keytool", Err: exec.ErrNotFound}}
+}
+
+// commands returns the command of every run, in order.
+func (f *fakeKeytool) commands() []string {
+	var commands []string
+	for _, args := range f.runs {
+		commands = append(commands, fakeKeytoolCommand(args))
+	}
+	return commands
+}
+
+func (f *fakeKeytool) Run(ctx context.Context, args ...string) ([]byte, error) {
+	f.t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs = append(f.runs, slices.Clone(args))
+
+	command := fakeKeytoolCommand(args)
+	if failure, ok := f.failures[""]; ok {
+		return nil, failure.err
+	}
+	if failure, ok := f.failures[command]; ok {
+		return []byte(failure.output), failure.err
+	}
+
+	var err error
+	switch command {
+	case "-genkeypair":
+		err = f.genkeypair(args)
+	case "-importkeystore":
+		err = f.importkeystore(args)
+	case "-exportcert":
+		err = f.exportcert(args)
+	case "-certreq":
+		err = f.certreq(args)
+	case "-importcert":
+		err = f.importcert(args)
+	case "-delete":
+		err = f.delete(args)
+	default:
+		err = fmt.Errorf("unexpected keytool command %s", command)
+	}
+	if errors.Is(err, ErrIncorrectPassword) {
+		return []byte("keytool error: java.io.IOException: keystore password was incorrect\n"), errFakeKeytoolExit
+	}
+	if err != nil {
+		// Runs may come from goroutines of the code under test, where
+		// Fatalf must not be called, so the test is failed with Errorf and
+		// the run fails.
+		err = fmt.Errorf("fake keytool %s: %w", command, err)
+		f.t.Errorf("%s", err)
+		return []byte(err.Error() + "\n"), err
+	}
+	return nil, nil
+}
+
+func (f *fakeKeytool) genkeypair(args []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	dname, err := ParseDistinguishedName(fakeKeytoolArg(args, "-dname"))
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dname.CommonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return err
+	}
+
+	return f.writeKeystore(args, fakeKeytoolArg(args, "-keystore"), StoreTypePKCS12, fakeKeytoolArg(args, "-storepass"), []KeystoreEntry{{
+		Alias:        fakeKeytoolArg(args, "-alias"),
+		Type:         EntryTypePrivateKey,
+		Key:          der,
+		Certificates: []*x509.Certificate{cert},
+	}})
+}
+
+func (f *fakeKeytool) importkeystore(args []string) error {
+	source, err := f.readKeystore(fakeKeytoolArg(args, "-srckeystore"), fakeKeytoolArg(args, "-srcstoretype"), fakeKeytoolArg(args, "-srcstorepass"))
+	if err != nil {
+		return err
+	}
+	entries := source.Entries
+	if alias := fakeKeytoolArg(args, "-srcalias"); alias != "" {
+		entry, ok := source.Entry(alias)
+		if !ok {
+			return newKeystoreError(ErrAliasNotFound, "alias %s not found", alias)
+		}
+		entries = []KeystoreEntry{*entry}
+	}
+
+	// Entries are added to an existing destination, replacing those of the
+	// same alias.
+	dest, storeType, password := fakeKeytoolArg(args, "-destkeystore"), fakeKeytoolArg(args, "-deststoretype"), fakeKeytoolArg(args, "-deststorepass")
+	existing, err := f.readKeystore(dest, storeType, password)
+	if err == nil {
+		for _, entry := range existing.Entries {
+			if !slices.ContainsFunc(entries, func(e KeystoreEntry) bool { return strings.EqualFold(e.Alias, entry.Alias) }) {
+				entries = append(entries, entry)
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return f.writeKeystore(args, dest, storeType, password, entries)
+}
+
+// importcert adds a trusted certificate entry, or installs a certificate
+// reply when the alias is a private key entry.
+func (f *fakeKeytool) importcert(args []string) error {
+	keystore, storeType, password := fakeKeytoolArg(args, "-keystore"), fakeKeytoolArg(args, "-storetype"), fakeKeytoolArg(args, "-storepass")
+	if storeType == "" {
+		storeType = StoreTypePKCS12
+	}
+	data, err := os.ReadFile(fakeKeytoolArg(args, "-file"))
+	if err != nil {
+		return err
+	}
+	certs, err := ParseCertificatesPEM(string(data))
+	if err != nil {
+		return err
+	}
+
+	ks, err := f.readKeystore(keystore, storeType, password)
+	if errors.Is(err, os.ErrNotExist) {
+		ks, err = &DecodedKeystore{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	alias := fakeKeytoolArg(args, "-alias")
+	if entry, ok := ks.Entry(alias); ok {
+		if entry.Type != EntryTypePrivateKey {
+			return fmt.Errorf("Certificate not imported, alias <%s> already exists", alias)
+		}
+		entry.Certificates = certs
+	} else {
+		ks.Entries = append(ks.Entries, KeystoreEntry{Alias: alias, Type: EntryTypeTrustedCertificate, Certificates: certs[:1]})
+	}
+	return f.writeKeystore(args, keystore, storeType, password, ks.Entries)
+}
+
+func (f *fakeKeytool) delete(args []string) error {
+	keystore, storeType, password := fakeKeytoolArg(args, "-keystore"), fakeKeytoolArg(args, "-storetype"), fakeKeytoolArg(args, "-storepass")
+	ks, err := f.readKeystore(keystore, storeType, password)
+	if err != nil {
+		return err
+	}
+	alias := fakeKeytoolArg(args, "-alias")
+	entries := slices.DeleteFunc(ks.Entries, func(e KeystoreEntry) bool { return strings.EqualFold(e.Alias, alias) })
+	if len(entries) == len(ks.Entries) {
+		return newKeystoreError(ErrAliasNotFound, "Alias <%s> does not exist", alias)
+	}
+	return f.writeKeystore(args, keystore, storeType, password, entries)
+}
+
+func (f *fakeKeytool) exportcert(args []string) error {
+	ks, err := f.readKeystore(fakeKeytoolArg(args, "-keystore"), StoreTypePKCS12, fakeKeytoolArg(args, "-storepass"))
+	if err != nil {
+		return err
+	}
+	entry, ok := ks.Entry(fakeKeytoolArg(args, "-alias"))
+	if !ok {
+		return newKeystoreError(ErrAliasNotFound, "alias not found")
+	}
+	return os.WriteFile(fakeKeytoolArg(args, "-file"), []byte(EncodeCertificatesPEM(entry.Certificates[:1])), 0600)
+}
+
+// certreq writes a certificate request for the key entry, labelled as
+// keytool labels it.
+func (f *fakeKeytool) certreq(args []string) error {
+	ks, err := f.readKeystore(fakeKeytoolArg(args, "-keystore"), StoreTypePKCS12, fakeKeytoolArg(args, "-storepass"))
+	if err != nil {
+		return err
+	}
+	entry, ok := ks.Entry(fakeKeytoolArg(args, "-alias"))
+	if !ok || entry.Type != EntryTypePrivateKey {
+		return newKeystoreError(ErrAliasNotFound, "alias not found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(entry.Key)
+	if err != nil {
+		return err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: entry.Certificates[0].Subject}, key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fakeKeytoolArg(args, "-file"), pem.EncodeToMemory(&pem.Block{Type: "NEW CERTIFICATE REQUEST", Bytes: der}), 0600)
+}
+
+func (f *fakeKeytool) readKeystore(path, storeType, password string) (*DecodedKeystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeKeystore(data, storeType, password)
+}
+
+// writeKeystore writes a keystore of storeType. PKCS12 keystores are
+// protected as the keystore.pkcs12 security properties in args require.
+func (f *fakeKeytool) writeKeystore(args []string, path, storeType, password string, entries []KeystoreEntry) error {
+	if storeType == StoreTypeJKS || storeType == StoreTypeJCEKS {
+		return os.WriteFile(path, testEncodeJKS(f.t, storeType, entries, password), 0600)
+	}
+	protection := legacyPKCS12Protection
+	if !f.ignoreProtection {
+		protection = fakeKeytoolProtection(args)
+	}
+	data, err := encodePKCS12(entries, password, protection)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// fakeKeytoolCommand returns the keytool command of args.
+func fakeKeytoolCommand(args []string) string {
+	for _, arg := range args {
+		if arg != "-v" {
+			return arg
+		}
+	}
+	return ""
+}
+
+// fakeKeytoolProtection returns the protection set by the keystore.pkcs12
+// security properties in args.
+func fakeKeytoolProtection(args []string) PKCS12Protection {
+	var p PKCS12Protection
+	for _, arg := range args {
+		property, ok := strings.CutPrefix(arg, "-J-Dkeystore.pkcs12.")
+		if !ok {
+			continue
+		}
+		name, value, _ := strings.Cut(property, "=")
+		n, _ := strconv.Atoi(value)
+		switch name {
+		case "keyProtectionAlgorithm":
+			p.KeyAlgorithm = value
+		case "keyPbeIterationCount":
+			p.KeyIterations = n
+		case "certProtectionAlgorithm":
+			p.CertificateAlgorithm = value
+		case "certPbeIterationCount":
+			p.CertificateIterations = n
+		case "macAlgorithm":
+			p.MacAlgorithm = value
+		case "macIterationCount":
+			p.MacIterations = n
+		}
+	}
+	return p
+}
+
+// fakeKeytoolArg returns the value of the keytool option flag, or the empty
+// string when it is not given.
+func fakeKeytoolArg(args []string, flag string) string {
+	if i := slices.Index(args, flag); i >= 0 && i+1 < len(args) {
+		return args[i+1]
+	}
+	return ""
+}
+
+func TestKeytoolRunnerFromProviderData(t *testing.T) {
+	if runner, diags := keytoolRunnerFromProviderData(nil); diags.HasError() || runner != (ExecKeytoolRunner{}) {
+		t.Errorf("expected the keytool executable without provider data, got %v", runner)
+	}
+
+	fake := newFakeKeytool(t)
+	if runner, diags := keytoolRunnerFromProviderData(fake); diags.HasError() || runner != fake {
+		t.Errorf("expected the runner of the provider data, got %v", runner)
+	}
+
+	if _, diags := keytoolRunnerFromProviderData("not a runner"); !diags.HasError() {
+		t.Error("expected unexpected provider data to be reported")
+	}
+}
+
+func TestKeytoolExitCode(t *testing.T) {
+	if code := keytoolExitCode(nil); code != 0 {
+		t.Errorf("expected 0 for a successful run, got %d", code)
+	}
+	if code := keytoolExitCode(&exec.Error{Name: "keytool", Err: exec.ErrNotFound}); code != -1 {
+		t.Errorf("expected -1 when keytool did not run, got %d", code)
+	}
+}
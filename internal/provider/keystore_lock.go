@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockRetryInterval is how often a lock held by another process is retried.
+var lockRetryInterval = 100 * time.Millisecond
+
+// lockFile takes an exclusive lock on the file at path, creating it if
+// needed, and waits for other holders until ctx is done. The lock is
+// advisory and only excludes other processes taking it the same way. The
+// returned function releases it.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file %s\nError: %s", path, err)
+	}
+
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error locking %s\nError: %s", path, err)
+		}
+		if locked {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("timed out waiting for the lock on %s\nError: %s", path, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
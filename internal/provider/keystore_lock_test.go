@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	ctx := context.Background()
+	lockPath := filepath.Join(t.TempDir(), "keystore.jks.lock")
+
+	unlock, err := lockFile(ctx, lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock is held by another open file, as it would be by another
+	// Terraform run.
+	waitCtx, cancel := context.WithTimeout(ctx, 3*lockRetryInterval)
+	defer cancel()
+	start := time.Now()
+	if _, err := lockFile(waitCtx, lockPath); err == nil {
+		t.Fatal("expected the held lock not to be taken")
+	}
+	if time.Since(start) < 3*lockRetryInterval {
+		t.Error("expected the lock to be waited for until the context is done")
+	}
+
+	unlock()
+	unlock, err = lockFile(ctx, lockPath)
+	if err != nil {
+		t.Fatalf("expected the released lock to be taken, got %s", err)
+	}
+	unlock()
+}
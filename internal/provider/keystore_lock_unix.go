@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build unix
+
+package provider
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// tryLockFile takes an exclusive flock on f without waiting, and reports
+// whether another process holds it.
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// fileOwnership returns the owner and group of a file as numeric ids for
+// LocalFile, or empty strings when they are those of the Terraform process
+// and need not be set.
+func fileOwnership(info os.FileInfo) (string, string) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	owner, group := "", ""
+	if int(stat.Uid) != os.Getuid() {
+		owner = strconv.FormatUint(uint64(stat.Uid), 10)
+	}
+	if int(stat.Gid) != os.Getgid() {
+		group = strconv.FormatUint(uint64(stat.Gid), 10)
+	}
+	return owner, group
+}
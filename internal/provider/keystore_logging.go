@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// logSubsystem is the tflog subsystem of keystore operations.
+const logSubsystem = "jks"
+
+// Fields of the keystore operation log lines.
+const (
+	logFieldOperation = "operation"
+	logFieldBackend   = "backend"
+	logFieldStoreType = "store_type"
+	logFieldAlgorithm = "key_algorithm"
+	logFieldAliases   = "aliases"
+	logFieldDuration  = "duration_ms"
+	logFieldExitCode  = "keytool_exit_code"
+	logFieldOutput    = "keytool_output"
+	logFieldError     = "error"
+)
+
+// Backends performing keystore operations.
+const (
+	logBackendKeytool = "keytool"
+	logBackendGo      = "go"
+)
+
+// logSecretFieldKeys are the fields whose values never appear in logs.
+var logSecretFieldKeys = []string{
+	"password",
+	"password_wo",
+	"previous_password_wo",
+	"source_password",
+	"target_password",
+	"ca_password",
+	"truststore_password",
+	"include_cacerts_password",
+	"private_key_pem",
+	"file",
+	"keystore",
+}
+
+// logPrivateKeyRegexp matches PEM encoded private keys, whatever field or
+// message they end up in.
+var logPrivateKeyRegexp = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+
+// keytoolPasswordFlags are the keytool options followed by a password.
+var keytoolPasswordFlags = []string{
+	"-storepass", "-keypass",
+	"-srcstorepass", "-srckeypass",
+	"-deststorepass", "-destkeypass",
+}
+
+// keystoreLogContext returns ctx with the jks subsystem, masking secret
+// fields, private keys and the given passwords in its log lines and in
+// those of the provider.
+func keystoreLogContext(ctx context.Context, passwords ...string) context.Context {
+	passwords = slices.DeleteFunc(slices.Clone(passwords), func(s string) bool { return s == "" })
+
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, logSecretFieldKeys...)
+	ctx = tflog.MaskLogRegexes(ctx, logPrivateKeyRegexp)
+	ctx = tflog.MaskLogStrings(ctx, passwords...)
+
+	ctx = tflog.NewSubsystem(ctx, logSubsystem, tflog.WithRootFields())
+	ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, logSubsystem, logSecretFieldKeys...)
+	ctx = tflog.SubsystemMaskLogRegexes(ctx, logSubsystem, logPrivateKeyRegexp)
+	return tflog.SubsystemMaskLogStrings(ctx, logSubsystem, passwords...)
+}
+
+// keytoolLogFields describes a keytool run by its arguments. Passwords are
+// left out.
+func keytoolLogFields(args []string) map[string]interface{} {
+	fields := map[string]interface{}{
+		logFieldBackend: logBackendKeytool,
+	}
+	// The command is the first option, after the verbose flag.
+	for _, arg := range args {
+		if arg != "-v" {
+			fields[logFieldOperation] = strings.TrimPrefix(arg, "-")
+			break
+		}
+	}
+
+	var aliases []string
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-storetype", "-srcstoretype", "-deststoretype":
+			fields[logFieldStoreType] = strings.ToUpper(args[i+1])
+		case "-keyalg":
+			fields[logFieldAlgorithm] = args[i+1]
+		case "-alias", "-srcalias":
+			aliases = append(aliases, args[i+1])
+		}
+	}
+	if len(aliases) > 0 {
+		fields[logFieldAliases] = aliases
+	}
+	return fields
+}
+
+// keytoolPasswords returns the passwords passed on the keytool command line.
+func keytoolPasswords(args []string) []string {
+	var passwords []string
+	for i := 0; i+1 < len(args); i++ {
+		if slices.Contains(keytoolPasswordFlags, args[i]) {
+			passwords = append(passwords, args[i+1])
+		}
+	}
+	return passwords
+}
+
+// logKeystoreOperation logs an operation of the Go backend that started at
+// start.
+func logKeystoreOperation(ctx context.Context, operation, storeType string, aliases []string, start time.Time, err error) {
+	fields := map[string]interface{}{
+		logFieldOperation: operation,
+		logFieldBackend:   logBackendGo,
+		logFieldStoreType: strings.ToUpper(storeType),
+		logFieldDuration:  time.Since(start).Milliseconds(),
+	}
+	if aliases != nil {
+		fields[logFieldAliases] = aliases
+	}
+	if err != nil {
+		fields[logFieldError] = err.Error()
+		tflog.SubsystemDebug(ctx, logSubsystem, "keystore operation failed", fields)
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystem, "keystore operation completed", fields)
+}
@@ -4,16 +4,81 @@
 package provider
 
 import (
+	"context"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type KeystoreModel struct {
 	Password          string
 	File              string
 	DistinguishedName DistinguishedName
+	KeyPair           KeyPair
+	// Protection sets the algorithms of the PKCS12 keystores written. The
+	// zero value leaves them to keytool.
+	Protection PKCS12Protection
+	// Runner runs keytool. The keytool executable is run when it is nil.
+	Runner KeytoolRunner
+}
+
+// Key algorithms accepted by keytool -genkeypair.
+const (
+	KeyAlgorithmRSA     = "RSA"
+	KeyAlgorithmEC      = "EC"
+	KeyAlgorithmEd25519 = "Ed25519"
+)
+
+// Signature algorithms accepted by keytool -genkeypair -sigalg.
+const (
+	SignatureAlgorithmSHA256WithRSA   = "SHA256withRSA"
+	SignatureAlgorithmSHA384WithRSA   = "SHA384withRSA"
+	SignatureAlgorithmSHA512WithRSA   = "SHA512withRSA"
+	SignatureAlgorithmRSASSAPSS       = "RSASSA-PSS"
+	SignatureAlgorithmSHA256WithECDSA = "SHA256withECDSA"
+	SignatureAlgorithmSHA384WithECDSA = "SHA384withECDSA"
+	SignatureAlgorithmEd25519         = "Ed25519"
+)
+
+// signatureAlgorithms are the signature algorithms a key of each key
+// algorithm can sign its certificate with.
+var signatureAlgorithms = map[string][]string{
+	KeyAlgorithmRSA:     {SignatureAlgorithmSHA256WithRSA, SignatureAlgorithmSHA384WithRSA, SignatureAlgorithmSHA512WithRSA, SignatureAlgorithmRSASSAPSS},
+	KeyAlgorithmEC:      {SignatureAlgorithmSHA256WithECDSA, SignatureAlgorithmSHA384WithECDSA},
+	KeyAlgorithmEd25519: {SignatureAlgorithmEd25519},
+}
+
+// KeyPair describes the key pair generated for a new keystore. The zero
+// value is a 2048 bit RSA key.
+type KeyPair struct {
+	Algorithm string
+	// SignatureAlgorithm signs the self-signed certificate, or is chosen
+	// by keytool from the key if empty.
+	SignatureAlgorithm string
+}
+
+// args returns the keytool -genkeypair arguments for the key pair.
+func (k KeyPair) args() []string {
+	var args []string
+	switch k.Algorithm {
+	case KeyAlgorithmEC:
+		args = []string{"-keyalg", KeyAlgorithmEC}
+	case KeyAlgorithmEd25519:
+		args = []string{"-keyalg", KeyAlgorithmEd25519}
+	default:
+		args = []string{"-keyalg", KeyAlgorithmRSA, "-keysize", "2048"}
+	}
+	if k.SignatureAlgorithm != "" {
+		args = append(args, "-sigalg", k.SignatureAlgorithm)
+	}
+	return args
 }
 
 type DistinguishedName struct {
@@ -25,92 +90,520 @@ type DistinguishedName struct {
 	Country            string
 }
 
+// String formats the name for keytool -dname. Values with separators or
+// surrounding spaces are quoted, so that ParseDistinguishedName reads them
+// back unchanged.
 func (d DistinguishedName) String() string {
-	return fmt.Sprintf("CN=%s, OU=%s, O=%s, L=%s, S=%s, C=%s", d.CommonName, d.OrganizationalUnit, d.Organization, d.Locality, d.State, d.Country)
+	return fmt.Sprintf("CN=%s, OU=%s, O=%s, L=%s, S=%s, C=%s", quoteDNValue(d.CommonName), quoteDNValue(d.OrganizationalUnit), quoteDNValue(d.Organization), quoteDNValue(d.Locality), quoteDNValue(d.State), quoteDNValue(d.Country))
 }
 
 const (
 	FILENAME  = "79e00021-58b2-4652-b498-59134c0ed6e7.pkcs12"
 	FILENAME2 = "79e00021-58b2-4652-b498-59134c0ed6e72.pkcs12"
+
+	// KeyAlias is the alias of the single key entry in generated keystores.
+	KeyAlias = "keystore"
 )
 
-func (m KeystoreModel) CreateKeystoreBase64() (string, error) {
-	fileName := FILENAME
+// workspace is a scratch directory holding the files handed to keytool.
+type workspace struct {
+	dir string
+}
+
+func newWorkspace() (*workspace, error) {
+	dir, err := os.MkdirTemp("", "terraform-provider-jks-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary directory for keytool\nError: %s", err)
+	}
+	return &workspace{dir: dir}, nil
+}
+
+func (w *workspace) path(name string) string {
+	return filepath.Join(w.dir, name)
+}
+
+// writeBase64 decodes b64 into the named file and returns its path.
+func (w *workspace) writeBase64(name, b64 string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return "", fmt.Errorf("error decoding base64 keystore file\nError: %s", err)
+	}
+	return w.write(name, decoded)
+}
+
+func (w *workspace) write(name string, data []byte) (string, error) {
+	path := w.path(name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("error writing file for keytool\nFile name: %s\nError: %s", path, err)
+	}
+	return path, nil
+}
+
+// read reads the named keystore file written by keytool.
+func (w *workspace) read(name string) ([]byte, error) {
+	bytes, err := os.ReadFile(w.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("error reading keystore file produced by keytool\nFile name: %s\nError: %s", name, err)
+	}
+	return bytes, nil
+}
+
+// readBase64 reads the named file and returns it base64 encoded.
+func (w *workspace) readBase64(name string) (string, error) {
+	bytes, err := w.read(name)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bytes), nil
+}
+
+// readPKCS12 reads the named PKCS12 keystore written by keytool, checks that
+// it is protected as the model requires and returns it base64 encoded.
+func (m KeystoreModel) readPKCS12(ws *workspace, name string) (string, error) {
+	bytes, err := ws.read(name)
+	if err != nil {
+		return "", err
+	}
+	if err := m.Protection.check(bytes); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bytes), nil
+}
+
+func (w *workspace) Close() error {
+	return os.RemoveAll(w.dir)
+}
 
-	cmd := exec.Command(
-		"keytool",
+func (m KeystoreModel) CreateKeystoreBase64(ctx context.Context) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password)
+
+	ws, err := newWorkspace()
+	if err != nil {
+		return "", err
+	}
+	defer ws.Close()
+
+	fileName := ws.path(FILENAME)
+
+	args := []string{
 		"-v",
 		"-genkeypair",
-		"-alias", "keystore",
+		"-alias", KeyAlias,
 		"-keypass", m.Password,
 		"-keystore", fileName,
 		"-storepass", m.Password,
 		"-validity", "10000",
-		"-keyalg", "RSA",
-		"-keysize", "2048",
 		"-dname", m.DistinguishedName.String(),
-	)
+	}
+	args = append(args, m.KeyPair.args()...)
+	_, err = m.runKeytool(ctx, append(args, m.Protection.args()...)...)
+	if err != nil {
+		return "", fmt.Errorf("error after executing command to produce keystore file\n%w", err)
+	}
 
-	output, err := cmd.CombinedOutput()
+	return m.readPKCS12(ws, FILENAME)
+}
+
+func (oldModel KeystoreModel) UpdateKeystoreBase64(ctx context.Context, newPassword string) (string, error) {
+	ctx = keystoreLogContext(ctx, oldModel.Password, newPassword)
+
+	ws, err := newWorkspace()
 	if err != nil {
-		return "", fmt.Errorf("error after executing command to produce keystore file. Is the keytool installed on the machine?\nError:%s\nOutput: %s", err, string(output))
+		return "", err
 	}
+	defer ws.Close()
 
-	bytes, err := os.ReadFile(fileName)
+	srcStoreType, err := detectBase64StoreType(oldModel.File)
 	if err != nil {
-		return "", fmt.Errorf("error reading keystore file after producing keystore file\nError: %s", err)
+		return "", fmt.Errorf("error changing password\n%w", err)
 	}
-	err = os.Remove(fileName)
+	fileName, err := ws.writeBase64(FILENAME, oldModel.File)
 	if err != nil {
-		return "", fmt.Errorf("error removing keystore file after reading keystore file\nError: %s", err)
+		return "", err
 	}
 
-	return base64.StdEncoding.EncodeToString(bytes), nil
+	err = oldModel.importKeystore(ctx, fileName, ws.path(FILENAME2), importKeystoreOptions{
+		SrcStoreType:  srcStoreType,
+		SrcPassword:   oldModel.Password,
+		DestStoreType: StoreTypePKCS12,
+		DestPassword:  newPassword,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error changing password\n%w", err)
+	}
+
+	return oldModel.readPKCS12(ws, FILENAME2)
 }
 
-func (oldModel KeystoreModel) UpdateKeystoreBase64(newPassword string) (string, error) {
+// importKeystoreOptions describe a keytool -importkeystore run.
+type importKeystoreOptions struct {
+	SrcStoreType  string
+	SrcPassword   string
+	DestStoreType string
+	DestPassword  string
+	// Aliases limits the import to the named entries. Every entry is
+	// imported when it is empty.
+	Aliases []string
+}
+
+// importKeystore copies entries from the keystore at src into the keystore
+// at dest, creating it if needed. Keys are protected with the destination
+// store password, as PKCS12 keystores require, and PKCS12 destinations with
+// the protection of the model.
+func (m KeystoreModel) importKeystore(ctx context.Context, src, dest string, o importKeystoreOptions) error {
+	args := []string{
+		"-importkeystore",
+		"-noprompt",
+		"-srckeystore", src,
+		"-srcstoretype", o.SrcStoreType,
+		"-srcstorepass", o.SrcPassword,
+		"-destkeystore", dest,
+		"-deststoretype", o.DestStoreType,
+		"-deststorepass", o.DestPassword,
+		"-destkeypass", o.DestPassword,
+	}
+	if o.DestStoreType == StoreTypePKCS12 {
+		args = append(args, m.Protection.args()...)
+	}
+
+	// keytool takes a single -srcalias, so subsets are imported one by one.
+	runs := [][]string{args}
+	if len(o.Aliases) > 0 {
+		runs = nil
+		for _, alias := range o.Aliases {
+			runs = append(runs, append(slices.Clone(args), "-srcalias", alias, "-srckeypass", o.SrcPassword))
+		}
+	}
+
+	for _, run := range runs {
+		if _, err := m.runKeytool(ctx, run...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	fileName := FILENAME
-	fileName2 := FILENAME2
+// ConvertKeystoreBase64 copies the entries of the keystore, or only those
+// named by aliases, into a keystore of destStoreType protected by
+// newPassword. A PEM destination is a bundle of unencrypted PKCS#8 keys
+// followed by their certificates, and does not use newPassword.
+func (m KeystoreModel) ConvertKeystoreBase64(ctx context.Context, srcStoreType, destStoreType, newPassword string, aliases []string) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password, newPassword)
 
-	decoded, err := base64.StdEncoding.DecodeString(oldModel.File)
+	ws, err := newWorkspace()
 	if err != nil {
 		return "", err
 	}
+	defer ws.Close()
 
-	if err := os.WriteFile(fileName, decoded, 0644); err != nil {
+	fileName, err := ws.writeBase64(FILENAME, m.File)
+	if err != nil {
 		return "", err
 	}
 
-	changePassCmd := exec.Command(
-		"keytool",
-		"-importkeystore",
-		"-srckeystore", fileName,
-		"-srcstoretype", "PKCS12",
-		"-srcstorepass", oldModel.Password,
-		"-destkeystore", fileName2,
-		"-deststoretype", "PKCS12",
-		"-deststorepass", newPassword,
-		"-destkeypass", newPassword,
+	opts := importKeystoreOptions{
+		SrcStoreType:  srcStoreType,
+		SrcPassword:   m.Password,
+		DestStoreType: destStoreType,
+		DestPassword:  newPassword,
+		Aliases:       aliases,
+	}
+	if destStoreType == StoreTypePEM {
+		// keytool cannot export private keys, so go through PKCS12.
+		opts.DestStoreType = StoreTypePKCS12
+		opts.DestPassword = m.Password
+	}
+
+	if err := m.importKeystore(ctx, fileName, ws.path(FILENAME2), opts); err != nil {
+		return "", fmt.Errorf("error converting keystore to %s\n%w", destStoreType, err)
+	}
+
+	if destStoreType == StoreTypePKCS12 {
+		return m.readPKCS12(ws, FILENAME2)
+	}
+	if destStoreType != StoreTypePEM {
+		return ws.readBase64(FILENAME2)
+	}
+
+	bytes, err := os.ReadFile(ws.path(FILENAME2))
+	if err != nil {
+		return "", fmt.Errorf("error reading keystore file produced by keytool\nError: %s", err)
+	}
+	ks, err := decodeKeystore(ctx, bytes, StoreTypePKCS12, m.Password)
+	if err != nil {
+		return "", fmt.Errorf("error decoding converted keystore\nError: %s", err)
+	}
+
+	var bundle strings.Builder
+	for _, entry := range ks.Entries {
+		s, err := entry.PEM()
+		if err != nil {
+			return "", err
+		}
+		bundle.WriteString(s)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(bundle.String())), nil
+}
+
+// CertificatePEM exports the certificate of the key entry as PEM.
+func (m KeystoreModel) CertificatePEM(ctx context.Context) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password)
+
+	ws, err := newWorkspace()
+	if err != nil {
+		return "", err
+	}
+	defer ws.Close()
+
+	fileName, err := ws.writeBase64(FILENAME, m.File)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = m.runKeytool(ctx,
+		"-exportcert",
+		"-rfc",
+		"-alias", KeyAlias,
+		"-keystore", fileName,
+		"-storepass", m.Password,
+		"-file", ws.path("certificate.pem"),
 	)
+	if err != nil {
+		return "", fmt.Errorf("error exporting certificate from keystore\n%w", err)
+	}
 
-	if out, err := changePassCmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("error changing password\nError: %s\nOutput: %s", err, string(out))
+	bytes, err := os.ReadFile(ws.path("certificate.pem"))
+	if err != nil {
+		return "", fmt.Errorf("error reading certificate exported from keystore\nError: %s", err)
 	}
+	return string(bytes), nil
+}
+
+// CertificateRequestPEM generates a PKCS#10 certificate request for the key
+// entry, to be signed by a CA and installed with InstallCertificateReply.
+// keytool labels the request NEW CERTIFICATE REQUEST; it is returned with
+// the CERTIFICATE REQUEST label other tools expect.
+func (m KeystoreModel) CertificateRequestPEM(ctx context.Context) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password)
 
-	if err := os.Remove(fileName); err != nil {
-		return "", fmt.Errorf("error removing old keystore file after changing password\nFile name: %s\nError: %s", fileName, err)
+	ws, err := newWorkspace()
+	if err != nil {
+		return "", err
 	}
+	defer ws.Close()
 
-	bytes, err := os.ReadFile(fileName2)
+	fileName, err := ws.writeBase64(FILENAME, m.File)
 	if err != nil {
+		return "", err
+	}
 
-		return "", fmt.Errorf("error reading new keystore file after changing password\nFile name: %s\nError: %s", fileName2, err)
+	_, err = m.runKeytool(ctx,
+		"-certreq",
+		"-alias", KeyAlias,
+		"-keystore", fileName,
+		"-storepass", m.Password,
+		"-file", ws.path("request.pem"),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error generating certificate request\n%w", err)
 	}
 
-	if err := os.Remove(fileName2); err != nil {
-		return "", fmt.Errorf("error removing keystore file after reading keystore file. Error: %s", err)
+	bytes, err := os.ReadFile(ws.path("request.pem"))
+	if err != nil {
+		return "", fmt.Errorf("error reading certificate request produced by keytool\nError: %s", err)
+	}
+	block, _ := pem.Decode(bytes)
+	if block == nil || (block.Type != "NEW CERTIFICATE REQUEST" && block.Type != "CERTIFICATE REQUEST") {
+		return "", fmt.Errorf("keytool produced no PEM encoded certificate request")
 	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: block.Bytes})), nil
+}
 
-	return base64.StdEncoding.EncodeToString(bytes), nil
+// InstallCertificateReply replaces the certificate of the key entry with a
+// CA-signed certificate and its chain, the equivalent of importing a
+// certificate reply with keytool. The signed certificate must carry the
+// public key of the existing private key.
+func (m KeystoreModel) InstallCertificateReply(ctx context.Context, signedCertificatePEM, caChainPEM string) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password)
+
+	signed, err := ParseCertificatesPEM(signedCertificatePEM)
+	if err != nil {
+		return "", fmt.Errorf("error parsing signed certificate\nError: %s", err)
+	}
+	if len(signed) != 1 {
+		return "", fmt.Errorf("expected exactly one signed certificate, found %d", len(signed))
+	}
+	chain, err := ParseCertificatesPEM(caChainPEM)
+	if err != nil {
+		return "", fmt.Errorf("error parsing CA chain\nError: %s", err)
+	}
+
+	currentPEM, err := m.CertificatePEM(ctx)
+	if err != nil {
+		return "", err
+	}
+	current, err := ParseCertificatesPEM(currentPEM)
+	if err != nil || len(current) == 0 {
+		return "", fmt.Errorf("error parsing certificate exported from keystore\nError: %v", err)
+	}
+	if !PublicKeysEqual(current[0].PublicKey, signed[0].PublicKey) {
+		return "", newKeystoreError(ErrCertificateMismatch, "the signed certificate for %q does not match the private key in the keystore", signed[0].Subject)
+	}
+
+	ws, err := newWorkspace()
+	if err != nil {
+		return "", err
+	}
+	defer ws.Close()
+
+	fileName, err := ws.writeBase64(FILENAME, m.File)
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := ws.write("reply.pem", []byte(EncodeCertificatesPEM(append(signed, chain...))))
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{
+		"-importcert",
+		"-noprompt",
+		"-trustcacerts",
+		"-alias", KeyAlias,
+		"-keystore", fileName,
+		"-storepass", m.Password,
+		"-keypass", m.Password,
+		"-file", reply,
+	}
+	_, err = m.runKeytool(ctx, append(args, m.Protection.args()...)...)
+	if err != nil {
+		return "", fmt.Errorf("error installing certificate reply\n%w", err)
+	}
+
+	return m.readPKCS12(ws, FILENAME)
+}
+
+// SigningOptions control the certificate issued by SignCertificateRequest.
+type SigningOptions struct {
+	ValidityDays     int64
+	KeyUsage         []string
+	ExtendedKeyUsage []string
+	DNSNames         []string
+	IPAddresses      []string
+}
+
+// extensions returns the keytool -ext arguments for the options. The
+// subject alternative names of the request are honored unless overridden.
+func (o SigningOptions) extensions() []string {
+	args := []string{"-ext", "honored=SAN"}
+	if len(o.KeyUsage) > 0 {
+		args = append(args, "-ext", "KU="+strings.Join(o.KeyUsage, ","))
+	}
+	if len(o.ExtendedKeyUsage) > 0 {
+		args = append(args, "-ext", "EKU="+strings.Join(o.ExtendedKeyUsage, ","))
+	}
+	var names []string
+	for _, name := range o.DNSNames {
+		names = append(names, "dns:"+name)
+	}
+	for _, ip := range o.IPAddresses {
+		names = append(names, "ip:"+ip)
+	}
+	if len(names) > 0 {
+		args = append(args, "-ext", "SAN="+strings.Join(names, ","))
+	}
+	return args
+}
+
+// SignCertificateRequest issues a certificate for the PEM encoded request
+// using the key entry alias of the keystore as the CA, and returns it as PEM.
+func (m KeystoreModel) SignCertificateRequest(ctx context.Context, alias, requestPEM string, opts SigningOptions) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password)
+
+	block, _ := pem.Decode([]byte(requestPEM))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "", fmt.Errorf("cert_request_pem does not contain a PEM encoded CERTIFICATE REQUEST")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("error parsing certificate request\nError: %s", err)
+	}
+	if err := request.CheckSignature(); err != nil {
+		return "", fmt.Errorf("invalid certificate request signature\nError: %s", err)
+	}
+
+	ws, err := newWorkspace()
+	if err != nil {
+		return "", err
+	}
+	defer ws.Close()
+
+	fileName, err := ws.writeBase64(FILENAME, m.File)
+	if err != nil {
+		return "", err
+	}
+	requestFile, err := ws.write("request.pem", []byte(requestPEM))
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{
+		"-gencert",
+		"-rfc",
+		"-alias", alias,
+		"-keystore", fileName,
+		"-storepass", m.Password,
+		"-infile", requestFile,
+		"-outfile", ws.path("certificate.pem"),
+		"-validity", strconv.FormatInt(opts.ValidityDays, 10),
+	}
+	args = append(args, opts.extensions()...)
+
+	_, err = m.runKeytool(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("error signing certificate request\n%w", err)
+	}
+
+	bytes, err := os.ReadFile(ws.path("certificate.pem"))
+	if err != nil {
+		return "", fmt.Errorf("error reading certificate produced by keytool\nError: %s", err)
+	}
+	return string(bytes), nil
+}
+
+// ImportKeystoreBase64 builds a PKCS12 keystore holding the PEM encoded
+// private key and its certificate chain under KeyAlias. Unlike the other
+// operations this does not need keytool, so existing keys can be taken over
+// without generating new ones.
+func (m KeystoreModel) ImportKeystoreBase64(ctx context.Context, privateKeyPEM, certificatePEM string) (string, error) {
+	ctx = keystoreLogContext(ctx, m.Password)
+
+	key, public, err := ParsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	certs, err := ParseCertificatesPEM(certificatePEM)
+	if err != nil {
+		return "", fmt.Errorf("error parsing certificate\nError: %s", err)
+	}
+	if len(certs) == 0 {
+		return "", fmt.Errorf("no PEM encoded certificate found")
+	}
+	if !PublicKeysEqual(public, certs[0].PublicKey) {
+		return "", newKeystoreError(ErrCertificateMismatch, "the certificate for %q does not match the private key", certs[0].Subject)
+	}
+
+	start := time.Now()
+	data, err := encodePKCS12([]KeystoreEntry{{
+		Alias:        KeyAlias,
+		Type:         EntryTypePrivateKey,
+		Key:          key,
+		Certificates: certs,
+	}}, m.Password, m.Protection)
+	logKeystoreOperation(ctx, "encode", StoreTypePKCS12, []string{KeyAlias}, start, err)
+	if err != nil {
+		return "", fmt.Errorf("error encoding keystore\nError: %s", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
 }
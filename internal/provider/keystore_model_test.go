@@ -0,0 +1,260 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testDecodeBase64Keystore decodes a base64 encoded PKCS12 keystore.
+func testDecodeBase64Keystore(t *testing.T, b64File, password string) *DecodedKeystore {
+	t.Helper()
+
+	data, err := base64.StdEncoding.DecodeString(b64File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := DecodeKeystore(data, StoreTypePKCS12, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestKeyPairArgs(t *testing.T) {
+	tests := []struct {
+		keyPair  KeyPair
+		expected []string
+	}{
+		{KeyPair{}, []string{"-keyalg", "RSA", "-keysize", "2048"}},
+		{KeyPair{Algorithm: KeyAlgorithmRSA}, []string{"-keyalg", "RSA", "-keysize", "2048"}},
+		{KeyPair{Algorithm: KeyAlgorithmEC}, []string{"-keyalg", "EC"}},
+		{KeyPair{Algorithm: KeyAlgorithmEd25519}, []string{"-keyalg", "Ed25519"}},
+		{KeyPair{SignatureAlgorithm: SignatureAlgorithmRSASSAPSS}, []string{"-keyalg", "RSA", "-keysize", "2048", "-sigalg", "RSASSA-PSS"}},
+		{KeyPair{Algorithm: KeyAlgorithmEC, SignatureAlgorithm: SignatureAlgorithmSHA384WithECDSA}, []string{"-keyalg", "EC", "-sigalg", "SHA384withECDSA"}},
+	}
+
+	for _, tt := range tests {
+		if args := tt.keyPair.args(); !slices.Equal(args, tt.expected) {
+			t.Errorf("expected %v for %+v, got %v", tt.expected, tt.keyPair, args)
+		}
+	}
+}
+
+func TestCreateKeystoreBase64(t *testing.T) {
+	fake := newFakeKeytool(t)
+	model := KeystoreModel{
+		Password:          "MyPassword12345",
+		DistinguishedName: DistinguishedName{CommonName: "service.example.com", Country: "NL"},
+		KeyPair:           KeyPair{Algorithm: KeyAlgorithmEC},
+		Runner:            fake,
+	}
+
+	b64File, err := model.CreateKeystoreBase64(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.runs) != 1 {
+		t.Fatalf("expected a single keytool run, got %v", fake.commands())
+	}
+	args := fake.runs[0]
+	for flag, expected := range map[string]string{
+		"-alias":     KeyAlias,
+		"-storepass": "MyPassword12345",
+		"-keypass":   "MyPassword12345",
+		"-dname":     "CN=service.example.com, OU=, O=, L=, S=, C=NL",
+		"-keyalg":    KeyAlgorithmEC,
+	} {
+		if value := fakeKeytoolArg(args, flag); value != expected {
+			t.Errorf("expected %s %q, got %q", flag, expected, value)
+		}
+	}
+	if slices.Contains(args, "-keysize") {
+		t.Error("expected no -keysize for an EC key")
+	}
+
+	ks := testDecodeBase64Keystore(t, b64File, "MyPassword12345")
+	if _, ok := ks.Entry(KeyAlias); !ok {
+		t.Errorf("expected an entry %s, got %v", KeyAlias, ks.Aliases())
+	}
+}
+
+func TestCreateKeystoreBase64Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*fakeKeytool)
+		kind  error
+	}{
+		{"keytool missing", func(f *fakeKeytool) { f.missing() }, ErrKeytoolNotFound},
+		{"unsupported curve", func(f *fakeKeytool) {
+			f.fail("-genkeypair", "keytool error: java.security.InvalidAlgorithmParameterException: Curve not supported: secp999r1")
+		}, ErrUnsupportedAlgorithm},
+		{"unrecognized", func(f *fakeKeytool) {
+			f.fail("-genkeypair", "keytool error: java.lang.Exception: something else")
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeKeytool(t)
+			tt.setup(fake)
+
+			_, err := KeystoreModel{Password: "MyPassword12345", Runner: fake}.CreateKeystoreBase64(context.Background())
+			var kerr *KeytoolError
+			if !errors.As(err, &kerr) {
+				t.Fatalf("expected a KeytoolError, got %v", err)
+			}
+			if kerr.Kind != tt.kind {
+				t.Errorf("expected kind %v, got %v", tt.kind, kerr.Kind)
+			}
+		})
+	}
+}
+
+func TestCreateKeystoreBase64Protection(t *testing.T) {
+	fake := newFakeKeytool(t)
+	model := KeystoreModel{
+		Password:   "MyPassword12345",
+		Protection: NewPKCS12Protection(PKCS12EncryptionLegacy, "", 0),
+		Runner:     fake,
+	}
+
+	b64File, err := model.CreateKeystoreBase64(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range []string{
+		"-J-Dkeystore.pkcs12.keyProtectionAlgorithm=PBEWithSHA1AndDESede",
+		"-J-Dkeystore.pkcs12.certProtectionAlgorithm=PBEWithSHA1AndRC2_40",
+		"-J-Dkeystore.pkcs12.macAlgorithm=HmacPBESHA1",
+		"-J-Dkeystore.pkcs12.macIterationCount=100000",
+	} {
+		if !slices.Contains(fake.runs[0], arg) {
+			t.Errorf("expected %s, got %v", arg, fake.runs[0])
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(b64File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protection, err := inspectPKCS12Protection(data); err != nil || protection != legacyPKCS12Protection {
+		t.Errorf("expected a legacy keystore, got %+v, %v", protection, err)
+	}
+
+	// keytool of older Java versions ignores the properties.
+	fake.ignoreProtection = true
+	model.Protection = NewPKCS12Protection(PKCS12EncryptionModern, "", 0)
+	if _, err := model.CreateKeystoreBase64(context.Background()); err == nil || !strings.Contains(err.Error(), "Java 8u301") {
+		t.Errorf("expected the ignored protection to be reported, got %v", err)
+	}
+
+	// Without a protection the keytool defaults are used as they are.
+	model.Protection = PKCS12Protection{}
+	if _, err := model.CreateKeystoreBase64(context.Background()); err != nil {
+		t.Errorf("expected the keytool defaults to be accepted, got %v", err)
+	}
+	if args := fake.runs[len(fake.runs)-1]; slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "-J-Dkeystore.pkcs12.") }) {
+		t.Errorf("expected no protection property, got %v", args)
+	}
+}
+
+func TestUpdateKeystoreBase64(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	model := KeystoreModel{Password: "MyPassword12345", Runner: fake}
+
+	b64File, err := model.CreateKeystoreBase64(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.File = b64File
+
+	updated, err := model.UpdateKeystoreBase64(ctx, "AnotherPassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDecodeBase64Keystore(t, updated, "AnotherPassword")
+
+	args := fake.runs[len(fake.runs)-1]
+	for flag, expected := range map[string]string{
+		"-srcstoretype":  StoreTypePKCS12,
+		"-srcstorepass":  "MyPassword12345",
+		"-deststoretype": StoreTypePKCS12,
+		"-deststorepass": "AnotherPassword",
+		"-destkeypass":   "AnotherPassword",
+	} {
+		if value := fakeKeytoolArg(args, flag); value != expected {
+			t.Errorf("expected %s %q, got %q", flag, expected, value)
+		}
+	}
+
+	// The keystore is not protected with the password of the model.
+	model.Password = "WrongPassword"
+	if _, err := model.UpdateKeystoreBase64(ctx, "AnotherPassword"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("expected ErrIncorrectPassword, got %v", err)
+	}
+
+	// The store type of the keystore is detected.
+	model.File = base64.StdEncoding.EncodeToString(testEncodeJKS(t, StoreTypeJKS, []KeystoreEntry{testTrustedEntry(t, "root", "Root CA")}, "changeit"))
+	model.Password = "changeit"
+	if _, err := model.UpdateKeystoreBase64(ctx, "AnotherPassword"); err != nil {
+		t.Fatal(err)
+	}
+	if storeType := fakeKeytoolArg(fake.runs[len(fake.runs)-1], "-srcstoretype"); storeType != StoreTypeJKS {
+		t.Errorf("expected -srcstoretype JKS, got %q", storeType)
+	}
+}
+
+func TestConvertKeystoreBase64Aliases(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	model := KeystoreModel{
+		Password: "changeit",
+		File:     testFixtureBase64(t, "testdata/openssl-modern.p12"),
+		Runner:   fake,
+	}
+
+	converted, err := model.ConvertKeystoreBase64(ctx, StoreTypePKCS12, StoreTypePKCS12, "AnotherPassword", []string{"server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aliases := testDecodeBase64Keystore(t, converted, "AnotherPassword").Aliases(); !slices.Equal(aliases, []string{"server"}) {
+		t.Errorf("expected the server entry, got %v", aliases)
+	}
+	if alias := fakeKeytoolArg(fake.runs[0], "-srcalias"); alias != "server" {
+		t.Errorf("expected -srcalias server, got %q", alias)
+	}
+
+	fake.fail("-importkeystore", "keytool error: java.lang.Exception: Alias <missing> does not exist")
+	if _, err := model.ConvertKeystoreBase64(ctx, StoreTypePKCS12, StoreTypePKCS12, "AnotherPassword", []string{"missing"}); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("expected ErrAliasNotFound, got %v", err)
+	}
+}
+
+func TestInstallCertificateReplyMismatch(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	model := KeystoreModel{Password: "MyPassword12345", Runner: fake}
+
+	b64File, err := model.CreateKeystoreBase64(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.File = b64File
+
+	// A certificate for another key is rejected before keytool installs it.
+	_, err = model.InstallCertificateReply(ctx, testSelfSignedCertificatePEM(t, "other.example.com"), "")
+	if !errors.Is(err, ErrCertificateMismatch) {
+		t.Errorf("expected ErrCertificateMismatch, got %v", err)
+	}
+	if commands := fake.commands(); !slices.Equal(commands, []string{"-genkeypair", "-exportcert"}) {
+		t.Errorf("expected the certificate reply not to be imported, got %v", commands)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseCertificatesPEM parses every CERTIFICATE block in s, in order. Blocks of
+// other types are rejected so that a private key pasted into a certificate
+// attribute is reported rather than silently dropped.
+func ParseCertificatesPEM(s string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(s)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %q, expected CERTIFICATE", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(strings.TrimSpace(string(rest))) != 0 {
+		return nil, errors.New("trailing data after the last PEM block")
+	}
+	return certs, nil
+}
+
+// EncodeCertificatesPEM encodes certs as concatenated CERTIFICATE blocks.
+func EncodeCertificatesPEM(certs []*x509.Certificate) string {
+	var b strings.Builder
+	for _, cert := range certs {
+		_ = pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return b.String()
+}
+
+// ParsePrivateKeyPEM parses a single PKCS#1, SEC 1 or PKCS#8 private key,
+// as the hashicorp/tls provider writes them, and returns it PKCS#8 encoded.
+func ParsePrivateKeyPEM(s string) ([]byte, crypto.PublicKey, error) {
+	block, rest := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, nil, errors.New("no PEM encoded private key found")
+	}
+	if len(strings.TrimSpace(string(rest))) != 0 {
+		return nil, nil, errors.New("trailing data after the private key")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unexpected PEM block of type %q, expected a private key", block.Type)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return der, publicKey(key), nil
+}
+
+// PublicKeysEqual reports whether a and b are the same public key.
+func PublicKeysEqual(a, b crypto.PublicKey) bool {
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
+
+// publicKey returns the public half of a private key parsed from PKCS#8.
+func publicKey(key any) crypto.PublicKey {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil
+	}
+	return signer.Public()
+}
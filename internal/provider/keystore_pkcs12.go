@@ -0,0 +1,561 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PKCS#12 (RFC 7292) decoding. Unlike golang.org/x/crypto/pkcs12 and its
+// forks, this keeps every bag and its attributes so that multi-entry
+// keystores written by keytool can be listed by alias.
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidSecretBag           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 5}
+	oidCertTypeX509        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidJavaTrustStore = asn1.ObjectIdentifier{2, 16, 840, 1, 113894, 746875, 1, 1}
+
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd128BitRC2CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 5}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+	oidPBES2                         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2                        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidAES128CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	oidHmacWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHmacWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHmacWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHmacWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHmacWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA224 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	Id         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	Id    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	Id   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pbes2Params struct {
+	Kdf              pkix.AlgorithmIdentifier
+	EncryptionScheme pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       asn1.RawValue
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	Prf        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// unmarshalDER is asn1.Unmarshal that rejects trailing data.
+func unmarshalDER(in []byte, out interface{}) error {
+	rest, err := asn1.Unmarshal(in, out)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing data after ASN.1 structure")
+	}
+	return nil
+}
+
+// pkcs12Bag is a safe bag with its attributes resolved.
+type pkcs12Bag struct {
+	safeBag
+	friendlyName string
+	localKeyID   string
+	trusted      bool
+}
+
+func decodePKCS12(data []byte, password string) ([]KeystoreEntry, error) {
+	bags, err := pkcs12Bags(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []KeystoreEntry
+	var keys []pkcs12Bag
+	certsByKeyID := map[string]*x509.Certificate{}
+	var pool []*x509.Certificate
+
+	for _, bag := range bags {
+		switch {
+		case bag.Id.Equal(oidKeyBag), bag.Id.Equal(oidPKCS8ShroudedKeyBag):
+			keys = append(keys, bag)
+		case bag.Id.Equal(oidCertBag):
+			cert, err := decodeCertBag(bag.Value.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case bag.localKeyID != "":
+				certsByKeyID[bag.localKeyID] = cert
+			case bag.trusted:
+				entries = append(entries, KeystoreEntry{
+					Alias:        bag.friendlyName,
+					Type:         EntryTypeTrustedCertificate,
+					Certificates: []*x509.Certificate{cert},
+				})
+			default:
+				pool = append(pool, cert)
+			}
+		case bag.Id.Equal(oidSecretBag):
+			entries = append(entries, KeystoreEntry{
+				Alias: bag.friendlyName,
+				Type:  EntryTypeSecretKey,
+			})
+		}
+	}
+
+	for _, bag := range keys {
+		key := bag.Value.Bytes
+		if bag.Id.Equal(oidPKCS8ShroudedKeyBag) {
+			var info encryptedPrivateKeyInfo
+			if err := unmarshalDER(bag.Value.Bytes, &info); err != nil {
+				return nil, fmt.Errorf("error reading encrypted private key: %w", err)
+			}
+			if key, err = pbDecrypt(info.Algorithm, info.EncryptedData, password); err != nil {
+				return nil, err
+			}
+		}
+		entry := KeystoreEntry{
+			Alias: bag.friendlyName,
+			Type:  EntryTypePrivateKey,
+			Key:   key,
+		}
+		if leaf, ok := certsByKeyID[bag.localKeyID]; ok {
+			entry.Certificates = buildChain(leaf, pool)
+			if entry.Alias == "" {
+				entry.Alias = certFriendlyName(bags, bag.localKeyID)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	// Java names entries without a friendly name by their position.
+	for i := range entries {
+		if entries[i].Alias == "" {
+			entries[i].Alias = strconv.Itoa(i + 1)
+		}
+	}
+	return entries, nil
+}
+
+func certFriendlyName(bags []pkcs12Bag, localKeyID string) string {
+	for _, bag := range bags {
+		if bag.Id.Equal(oidCertBag) && bag.localKeyID == localKeyID {
+			return bag.friendlyName
+		}
+	}
+	return ""
+}
+
+// pkcs12Bags verifies the integrity of a PFX and returns all of its safe
+// bags, decrypting encrypted safe contents.
+func pkcs12Bags(data []byte, password string) ([]pkcs12Bag, error) {
+	pfx := new(pfxPdu)
+	if err := unmarshalDER(data, pfx); err != nil {
+		return nil, fmt.Errorf("error reading PKCS12 data: %w", err)
+	}
+	if pfx.Version != 3 {
+		return nil, fmt.Errorf("unsupported PKCS12 version %d", pfx.Version)
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, errors.New("only password integrity protected PKCS12 files are supported")
+	}
+
+	var authSafeData []byte
+	if err := unmarshalDER(pfx.AuthSafe.Content.Bytes, &authSafeData); err != nil {
+		return nil, fmt.Errorf("error reading PKCS12 authenticated safe: %w", err)
+	}
+
+	// Password-less stores, such as the truststores Java 18 and later write
+	// without a password, come without a MAC. As Java does, their integrity
+	// check is skipped whatever the password.
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) != 0 {
+		if err := verifyMac(&pfx.MacData, authSafeData, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var authenticatedSafe []contentInfo
+	if err := unmarshalDER(authSafeData, &authenticatedSafe); err != nil {
+		return nil, fmt.Errorf("error reading PKCS12 authenticated safe: %w", err)
+	}
+
+	var bags []pkcs12Bag
+	for _, ci := range authenticatedSafe {
+		var content []byte
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if err := unmarshalDER(ci.Content.Bytes, &content); err != nil {
+				return nil, err
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if err := unmarshalDER(ci.Content.Bytes, &ed); err != nil {
+				return nil, err
+			}
+			var err error
+			content, err = pbDecrypt(ed.EncryptedContentInfo.ContentEncryptionAlgorithm, ed.EncryptedContentInfo.EncryptedContent, password)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported PKCS12 content type %s", ci.ContentType)
+		}
+
+		var safeContents []safeBag
+		if err := unmarshalDER(content, &safeContents); err != nil {
+			return nil, fmt.Errorf("error reading PKCS12 safe contents: %w", err)
+		}
+		for _, sb := range safeContents {
+			bag, err := resolveBagAttributes(sb)
+			if err != nil {
+				return nil, err
+			}
+			bags = append(bags, bag)
+		}
+	}
+	return bags, nil
+}
+
+func resolveBagAttributes(sb safeBag) (pkcs12Bag, error) {
+	bag := pkcs12Bag{safeBag: sb}
+	for _, attr := range sb.Attributes {
+		switch {
+		case attr.Id.Equal(oidFriendlyName):
+			var name asn1.RawValue
+			if err := unmarshalDER(attr.Value.Bytes, &name); err != nil {
+				return bag, fmt.Errorf("error reading friendly name: %w", err)
+			}
+			s, err := decodeBMPString(name.Bytes)
+			if err != nil {
+				return bag, err
+			}
+			bag.friendlyName = s
+		case attr.Id.Equal(oidLocalKeyID):
+			var id []byte
+			if err := unmarshalDER(attr.Value.Bytes, &id); err != nil {
+				return bag, fmt.Errorf("error reading local key id: %w", err)
+			}
+			bag.localKeyID = hex.EncodeToString(id)
+		case attr.Id.Equal(oidJavaTrustStore):
+			bag.trusted = true
+		}
+	}
+	return bag, nil
+}
+
+func decodeCertBag(der []byte) (*x509.Certificate, error) {
+	var bag certBag
+	if err := unmarshalDER(der, &bag); err != nil {
+		return nil, fmt.Errorf("error reading certificate bag: %w", err)
+	}
+	if !bag.Id.Equal(oidCertTypeX509) {
+		return nil, fmt.Errorf("unsupported certificate type %s", bag.Id)
+	}
+	return x509.ParseCertificate(bag.Data)
+}
+
+func verifyMac(md *macData, message []byte, password string) error {
+	h, err := hashForOID(md.Mac.Algorithm.Algorithm)
+	if err != nil {
+		return fmt.Errorf("unsupported PKCS12 MAC algorithm: %w", err)
+	}
+	if err := checkIterations(md.Iterations); err != nil {
+		return fmt.Errorf("error reading PKCS12 MAC: %w", err)
+	}
+	key := pkcs12KDF(h, md.MacSalt, bmpPassword(password), md.Iterations, 3, h().Size())
+	mac := hmac.New(h, key)
+	mac.Write(message)
+	if !hmac.Equal(mac.Sum(nil), md.Mac.Digest) {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+func hashForOID(oid asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1), oid.Equal(oidHmacWithSHA1):
+		return sha1.New, nil
+	case oid.Equal(oidSHA224), oid.Equal(oidHmacWithSHA224):
+		return sha256.New224, nil
+	case oid.Equal(oidSHA256), oid.Equal(oidHmacWithSHA256):
+		return sha256.New, nil
+	case oid.Equal(oidSHA384), oid.Equal(oidHmacWithSHA384):
+		return sha512.New384, nil
+	case oid.Equal(oidSHA512), oid.Equal(oidHmacWithSHA512):
+		return sha512.New, nil
+	}
+	return nil, newKeystoreError(ErrUnsupportedAlgorithm, "unknown digest algorithm %s", oid)
+}
+
+// pbDecrypt decrypts data encrypted with one of the password based schemes
+// keytool and OpenSSL use in PKCS12 files.
+func pbDecrypt(alg pkix.AlgorithmIdentifier, encrypted []byte, password string) ([]byte, error) {
+	block, iv, err := pbCipher(alg, password)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := block.BlockSize()
+	if len(encrypted) == 0 || len(encrypted)%bs != 0 {
+		return nil, errors.New("encrypted data is not a multiple of the cipher block size")
+	}
+	decrypted := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, encrypted)
+
+	// A wrong password almost always shows up as invalid padding.
+	n := int(decrypted[len(decrypted)-1])
+	if n == 0 || n > bs || !bytes.Equal(decrypted[len(decrypted)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, ErrIncorrectPassword
+	}
+	return decrypted[:len(decrypted)-n], nil
+}
+
+func pbCipher(alg pkix.AlgorithmIdentifier, password string) (cipher.Block, []byte, error) {
+	switch {
+	case alg.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC),
+		alg.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC),
+		alg.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+		var params pbeParams
+		if err := unmarshalDER(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, fmt.Errorf("error reading PBE parameters: %w", err)
+		}
+		if err := checkIterations(params.Iterations); err != nil {
+			return nil, nil, fmt.Errorf("error reading PBE parameters: %w", err)
+		}
+		bmp := bmpPassword(password)
+		iv := pkcs12KDF(sha1.New, params.Salt, bmp, params.Iterations, 2, 8)
+
+		var block cipher.Block
+		var err error
+		switch {
+		case alg.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC):
+			block, err = des.NewTripleDESCipher(pkcs12KDF(sha1.New, params.Salt, bmp, params.Iterations, 1, 24))
+		case alg.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC):
+			block = newRC2Cipher(pkcs12KDF(sha1.New, params.Salt, bmp, params.Iterations, 1, 16), 128)
+		default:
+			block = newRC2Cipher(pkcs12KDF(sha1.New, params.Salt, bmp, params.Iterations, 1, 5), 40)
+		}
+		return block, iv, err
+
+	case alg.Algorithm.Equal(oidPBES2):
+		var params pbes2Params
+		if err := unmarshalDER(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, fmt.Errorf("error reading PBES2 parameters: %w", err)
+		}
+		if !params.Kdf.Algorithm.Equal(oidPBKDF2) {
+			return nil, nil, newKeystoreError(ErrUnsupportedAlgorithm, "unsupported PBES2 key derivation function %s", params.Kdf.Algorithm)
+		}
+		var kdf pbkdf2Params
+		if err := unmarshalDER(params.Kdf.Parameters.FullBytes, &kdf); err != nil {
+			return nil, nil, fmt.Errorf("error reading PBKDF2 parameters: %w", err)
+		}
+		if err := checkIterations(kdf.Iterations); err != nil {
+			return nil, nil, fmt.Errorf("error reading PBKDF2 parameters: %w", err)
+		}
+		if kdf.Salt.Tag != asn1.TagOctetString {
+			return nil, nil, errors.New("only explicitly specified PBKDF2 salts are supported")
+		}
+		prf := sha1.New
+		if len(kdf.Prf.Algorithm) != 0 {
+			var err error
+			if prf, err = hashForOID(kdf.Prf.Algorithm); err != nil {
+				return nil, nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function: %w", err)
+			}
+		}
+
+		var iv []byte
+		if err := unmarshalDER(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+			return nil, nil, fmt.Errorf("error reading PBES2 initialization vector: %w", err)
+		}
+
+		var keyLen int
+		var newCipher func([]byte) (cipher.Block, error)
+		switch {
+		case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+			keyLen, newCipher = 16, aes.NewCipher
+		case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+			keyLen, newCipher = 24, aes.NewCipher
+		case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+			keyLen, newCipher = 32, aes.NewCipher
+		case params.EncryptionScheme.Algorithm.Equal(oidDESEDE3CBC):
+			keyLen, newCipher = 24, des.NewTripleDESCipher
+		default:
+			return nil, nil, newKeystoreError(ErrUnsupportedAlgorithm, "unsupported PBES2 encryption scheme %s", params.EncryptionScheme.Algorithm)
+		}
+
+		block, err := newCipher(pbkdf2.Key([]byte(password), kdf.Salt.Bytes, kdf.Iterations, keyLen, prf))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(iv) != block.BlockSize() {
+			return nil, nil, errors.New("PBES2 initialization vector has the wrong length")
+		}
+		return block, iv, nil
+	}
+	return nil, nil, newKeystoreError(ErrUnsupportedAlgorithm, "unsupported encryption algorithm %s", alg.Algorithm)
+}
+
+// bmpPassword encodes a password the way PKCS#12 key derivation expects it,
+// as a zero terminated big endian UTF-16 string.
+func bmpPassword(password string) []byte {
+	units := utf16.Encode([]rune(password))
+	b := make([]byte, 0, 2*len(units)+2)
+	for _, u := range units {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return append(b, 0, 0)
+}
+
+func decodeBMPString(b []byte) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("BMPString has an odd length")
+	}
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	if n := len(units); n > 0 && units[n-1] == 0 {
+		units = units[:n-1]
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// pkcs12KDF implements the key derivation function of RFC 7292, appendix B.
+// id is 1 for keys, 2 for initialization vectors and 3 for MAC keys.
+func pkcs12KDF(h func() hash.Hash, salt, password []byte, iterations int, id byte, size int) []byte {
+	v := h().BlockSize()
+
+	d := bytes.Repeat([]byte{id}, v)
+	i := append(fillWithRepeats(salt, v), fillWithRepeats(password, v)...)
+
+	var out []byte
+	for len(out) < size {
+		digest := h()
+		digest.Write(d)
+		digest.Write(i)
+		a := digest.Sum(nil)
+		for r := 1; r < iterations; r++ {
+			digest.Reset()
+			digest.Write(a)
+			a = digest.Sum(a[:0])
+		}
+		out = append(out, a...)
+
+		if len(out) < size {
+			b := fillWithRepeats(a, v)[:v]
+			for j := 0; j < len(i); j += v {
+				// I_j = (I_j + B + 1) mod 2^(8v)
+				carry := 1
+				for k := v - 1; k >= 0; k-- {
+					sum := int(i[j+k]) + int(b[k]) + carry
+					i[j+k] = byte(sum)
+					carry = sum >> 8
+				}
+			}
+		}
+	}
+	return out[:size]
+}
+
+// fillWithRepeats concatenates copies of pattern up to the next multiple of v.
+func fillWithRepeats(pattern []byte, v int) []byte {
+	if len(pattern) == 0 {
+		return nil
+	}
+	n := v * ((len(pattern) + v - 1) / v)
+	return bytes.Repeat(pattern, (n+len(pattern)-1)/len(pattern))[:n]
+}
@@ -0,0 +1,315 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// PKCS#12 encoding, with the algorithms of a PKCS12Protection. The zero
+// value uses those keytool uses by default since Java 17: PBES2 with
+// PBKDF2-HMAC-SHA256 and AES-256-CBC for keys and certificates, and an
+// HMAC-SHA256 integrity MAC.
+
+var oidAnyExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+
+// encodePKCS12 writes entries as a PKCS12 keystore protected by password.
+// Keys are protected with the same password, as keytool requires. Without
+// a password, the keystore is written as Java 18 and later write
+// password-less truststores, with unencrypted certificates and no integrity
+// MAC. Such a keystore cannot hold private keys.
+func encodePKCS12(entries []KeystoreEntry, password string, protection PKCS12Protection) ([]byte, error) {
+	protection = protection.orModern()
+	mac, ok := pkcs12MacAlgorithms[protection.MacAlgorithm]
+	if !ok {
+		return nil, newKeystoreError(ErrUnsupportedAlgorithm, "unsupported PKCS12 MAC algorithm %s", protection.MacAlgorithm)
+	}
+
+	var keyBags, certBags []safeBag
+
+	for _, entry := range entries {
+		friendlyName, err := pkcs12FriendlyName(entry.Alias)
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry.Type {
+		case EntryTypePrivateKey:
+			if len(entry.Certificates) == 0 {
+				return nil, fmt.Errorf("private key entry %q has no certificate", entry.Alias)
+			}
+			if password == "" {
+				return nil, fmt.Errorf("private key entry %q cannot be written to a keystore without a password", entry.Alias)
+			}
+			id := sha1.Sum(entry.Certificates[0].Raw)
+			localKeyID, err := newPKCS12Attribute(oidLocalKeyID, id[:])
+			if err != nil {
+				return nil, err
+			}
+
+			alg, encrypted, err := pbEncrypt(entry.Key, password, protection.KeyAlgorithm, protection.KeyIterations)
+			if err != nil {
+				return nil, err
+			}
+			key, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: alg, EncryptedData: encrypted})
+			if err != nil {
+				return nil, err
+			}
+			keyBags = append(keyBags, safeBag{
+				Id:         oidPKCS8ShroudedKeyBag,
+				Value:      explicitTag(key),
+				Attributes: []pkcs12Attribute{friendlyName, localKeyID},
+			})
+
+			for i, cert := range entry.Certificates {
+				var attributes []pkcs12Attribute
+				if i == 0 {
+					attributes = []pkcs12Attribute{friendlyName, localKeyID}
+				}
+				bag, err := pkcs12CertBag(cert.Raw, attributes)
+				if err != nil {
+					return nil, err
+				}
+				certBags = append(certBags, bag)
+			}
+
+		case EntryTypeTrustedCertificate:
+			trusted, err := newPKCS12Attribute(oidJavaTrustStore, oidAnyExtendedKeyUsage)
+			if err != nil {
+				return nil, err
+			}
+			bag, err := pkcs12CertBag(entry.Certificates[0].Raw, []pkcs12Attribute{friendlyName, trusted})
+			if err != nil {
+				return nil, err
+			}
+			certBags = append(certBags, bag)
+
+		default:
+			return nil, fmt.Errorf("entry %q is a %s and cannot be written to a PKCS12 keystore", entry.Alias, entry.Type)
+		}
+	}
+
+	var authenticatedSafe []contentInfo
+	if len(certBags) > 0 {
+		content, err := asn1.Marshal(certBags)
+		if err != nil {
+			return nil, err
+		}
+		var ci contentInfo
+		if password == "" {
+			ci, err = pkcs12DataContentInfo(content)
+		} else {
+			ci, err = pkcs12EncryptedContentInfo(content, password, protection.CertificateAlgorithm, protection.CertificateIterations)
+		}
+		if err != nil {
+			return nil, err
+		}
+		authenticatedSafe = append(authenticatedSafe, ci)
+	}
+	if len(keyBags) > 0 {
+		content, err := asn1.Marshal(keyBags)
+		if err != nil {
+			return nil, err
+		}
+		ci, err := pkcs12DataContentInfo(content)
+		if err != nil {
+			return nil, err
+		}
+		authenticatedSafe = append(authenticatedSafe, ci)
+	}
+
+	authSafeData, err := asn1.Marshal(authenticatedSafe)
+	if err != nil {
+		return nil, err
+	}
+	authSafe, err := pkcs12DataContentInfo(authSafeData)
+	if err != nil {
+		return nil, err
+	}
+
+	if password == "" {
+		return asn1.Marshal(pfxPdu{Version: 3, AuthSafe: authSafe})
+	}
+
+	salt, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	macKey := pkcs12KDF(mac.hash, salt, bmpPassword(password), protection.MacIterations, 3, mac.hash().Size())
+	digest := hmac.New(mac.hash, macKey)
+	digest.Write(authSafeData)
+
+	return asn1.Marshal(pfxPdu{
+		Version:  3,
+		AuthSafe: authSafe,
+		MacData: macData{
+			Mac: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: mac.oid, Parameters: asn1.NullRawValue},
+				Digest:    digest.Sum(nil),
+			},
+			MacSalt:    salt,
+			Iterations: protection.MacIterations,
+		},
+	})
+}
+
+func pkcs12DataContentInfo(content []byte) (contentInfo, error) {
+	data, err := asn1.Marshal(content)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{
+		ContentType: oidDataContentType,
+		Content:     explicitTag(data),
+	}, nil
+}
+
+// pkcs12EncryptedContentInfo encrypts content with the named password based
+// encryption algorithm.
+func pkcs12EncryptedContentInfo(content []byte, password, algorithm string, iterations int) (contentInfo, error) {
+	alg, encrypted, err := pbEncrypt(content, password, algorithm, iterations)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	ed, err := asn1.Marshal(encryptedData{
+		Version: 0,
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidDataContentType,
+			ContentEncryptionAlgorithm: alg,
+			EncryptedContent:           encrypted,
+		},
+	})
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{
+		ContentType: oidEncryptedDataContentType,
+		Content:     explicitTag(ed),
+	}, nil
+}
+
+func pkcs12CertBag(der []byte, attributes []pkcs12Attribute) (safeBag, error) {
+	bag, err := asn1.Marshal(certBag{Id: oidCertTypeX509, Data: der})
+	if err != nil {
+		return safeBag{}, err
+	}
+	return safeBag{
+		Id:         oidCertBag,
+		Value:      explicitTag(bag),
+		Attributes: attributes,
+	}, nil
+}
+
+// explicitTag wraps der in the [0] EXPLICIT tag of the structures above.
+// encoding/asn1 writes raw values as they are, ignoring their field tags.
+func explicitTag(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+// newPKCS12Attribute returns a bag attribute with a single value.
+func newPKCS12Attribute(id asn1.ObjectIdentifier, value interface{}) (pkcs12Attribute, error) {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		return pkcs12Attribute{}, err
+	}
+	return pkcs12Attribute{
+		Id:    id,
+		Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: der},
+	}, nil
+}
+
+// pkcs12FriendlyName returns a friendly name attribute.
+func pkcs12FriendlyName(name string) (pkcs12Attribute, error) {
+	bmp := bmpPassword(name)
+	return newPKCS12Attribute(oidFriendlyName, asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagBMPString, Bytes: bmp[:len(bmp)-2]})
+}
+
+// pbEncrypt encrypts data with the named password based encryption
+// algorithm, the inverse of pbDecrypt.
+func pbEncrypt(data []byte, password, algorithm string, iterations int) (pkix.AlgorithmIdentifier, []byte, error) {
+	alg, err := newPBEAlgorithm(algorithm, iterations)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	block, iv, err := pbCipher(alg, password)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	bs := block.BlockSize()
+	n := bs - len(data)%bs
+	padded := append(bytes.Clone(data), bytes.Repeat([]byte{byte(n)}, n)...)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+	return alg, encrypted, nil
+}
+
+// newPBEAlgorithm returns the algorithm identifier of the named password
+// based encryption algorithm, with a random salt and initialization vector.
+func newPBEAlgorithm(algorithm string, iterations int) (pkix.AlgorithmIdentifier, error) {
+	switch algorithm {
+	case pbe3DES, pbeRC240:
+		// Java uses salts of 20 bytes for the PKCS#12 PBE schemes.
+		salt, err := randomBytes(20)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		params, err := asn1.Marshal(pbeParams{Salt: salt, Iterations: iterations})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		oid := oidPBEWithSHAAnd3KeyTripleDESCBC
+		if algorithm == pbeRC240 {
+			oid = oidPBEWithSHAAnd40BitRC2CBC
+		}
+		return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: params}}, nil
+
+	case pbeAES256:
+		salt, err := randomBytes(16)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		iv, err := randomBytes(aes.BlockSize)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		kdfParams, err := asn1.Marshal(pbkdf2Params{
+			Salt:       asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagOctetString, Bytes: salt},
+			Iterations: iterations,
+			Prf:        pkix.AlgorithmIdentifier{Algorithm: oidHmacWithSHA256, Parameters: asn1.NullRawValue},
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		ivParam, err := asn1.Marshal(iv)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		params, err := asn1.Marshal(pbes2Params{
+			Kdf:              pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+			EncryptionScheme: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		return pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}}, nil
+	}
+	return pkix.AlgorithmIdentifier{}, newKeystoreError(ErrUnsupportedAlgorithm, "unsupported PKCS12 encryption algorithm %s", algorithm)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating random bytes: %w", err)
+	}
+	return b, nil
+}
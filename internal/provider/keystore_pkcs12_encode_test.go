@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodePKCS12(t *testing.T) {
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	ca := testFixtureEntry(t, "testdata/go-pkcs12-truststore.p12", "changeit", "test ca")
+
+	data, err := encodePKCS12([]KeystoreEntry{*server, *ca}, "another-password", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := DecodeKeystore(data, StoreTypePKCS12, "another-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.Entries) != 2 {
+		t.Fatalf("expected 2 entries, found %d", len(ks.Entries))
+	}
+
+	entry, ok := ks.Entry("server")
+	if !ok {
+		t.Fatal("entry server not found")
+	}
+	if entry.Type != EntryTypePrivateKey || !bytes.Equal(entry.Key, server.Key) {
+		t.Error("expected the private key to be kept")
+	}
+	if len(entry.Certificates) != 2 || !entry.Certificates[0].Equal(server.Certificates[0]) || !entry.Certificates[1].Equal(server.Certificates[1]) {
+		t.Error("expected the certificate chain to be kept")
+	}
+
+	entry, ok = ks.Entry("test ca")
+	if !ok {
+		t.Fatal("entry test ca not found")
+	}
+	if entry.Type != EntryTypeTrustedCertificate || !entry.Certificates[0].Equal(ca.Certificates[0]) {
+		t.Error("expected the trusted certificate to be kept")
+	}
+
+	if _, err := DecodeKeystore(data, StoreTypePKCS12, "changeit"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("expected ErrIncorrectPassword, got %v", err)
+	}
+}
+
+func TestEncodePKCS12SecretKey(t *testing.T) {
+	_, err := encodePKCS12([]KeystoreEntry{{Alias: "secret", Type: EntryTypeSecretKey}}, "changeit", PKCS12Protection{})
+	if err == nil {
+		t.Error("expected secret key entries to be rejected")
+	}
+}
+
+func TestEncodePKCS12Protection(t *testing.T) {
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+
+	for name, protection := range map[string]PKCS12Protection{
+		PKCS12EncryptionModern: NewPKCS12Protection(PKCS12EncryptionModern, "", 0),
+		PKCS12EncryptionLegacy: NewPKCS12Protection(PKCS12EncryptionLegacy, "", 0),
+		PKCS12EncryptionCustom: NewPKCS12Protection(PKCS12EncryptionCustom, MacAlgorithmSHA512, 20000),
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := encodePKCS12([]KeystoreEntry{*server}, "another-password", protection)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DecodeKeystore(data, StoreTypePKCS12, "another-password"); err != nil {
+				t.Fatal(err)
+			}
+
+			found, err := inspectPKCS12Protection(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != protection {
+				t.Errorf("expected %+v, got %+v", protection, found)
+			}
+			if err := protection.check(data); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPKCS12ProtectionCheck(t *testing.T) {
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	data, err := encodePKCS12([]KeystoreEntry{*server}, "changeit", legacyPKCS12Protection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = modernPKCS12Protection.check(data)
+	if err == nil || !strings.Contains(err.Error(), "MAC algorithm is HmacPBESHA1 instead of HmacPBESHA256") {
+		t.Errorf("expected the legacy MAC to be reported, got %v", err)
+	}
+	if err := (PKCS12Protection{}).check(data); err != nil {
+		t.Errorf("expected any protection to be accepted without one requested, got %v", err)
+	}
+}
+
+func TestEncodePKCS12WithoutPassword(t *testing.T) {
+	ca := testFixtureEntry(t, "testdata/go-pkcs12-truststore.p12", "changeit", "test ca")
+
+	data, err := encodePKCS12([]KeystoreEntry{*ca}, "", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := inspectPKCS12Protection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != (PKCS12Protection{}) {
+		t.Errorf("expected neither a MAC nor encryption, got %+v", found)
+	}
+
+	// As in Java, the password of a store without MAC is not checked.
+	for _, password := range []string{"", "changeit"} {
+		ks, err := DecodeKeystore(data, StoreTypePKCS12, password)
+		if err != nil {
+			t.Fatalf("decoding with password %q: %s", password, err)
+		}
+		entry, ok := ks.Entry("test ca")
+		if !ok || entry.Type != EntryTypeTrustedCertificate || !entry.Certificates[0].Equal(ca.Certificates[0]) {
+			t.Error("expected the trusted certificate to be kept")
+		}
+	}
+
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	if _, err := encodePKCS12([]KeystoreEntry{*server}, "", PKCS12Protection{}); err == nil {
+		t.Error("expected private keys to require a password")
+	}
+}
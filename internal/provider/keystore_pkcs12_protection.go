@@ -0,0 +1,273 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// PKCS#12 encryption profiles of jks_keystore.
+const (
+	PKCS12EncryptionModern = "modern"
+	PKCS12EncryptionLegacy = "legacy"
+	PKCS12EncryptionCustom = "custom"
+)
+
+// Password based encryption algorithms of PKCS12 keystores, named as the
+// keystore.pkcs12 security properties of Java take them.
+const (
+	pbeAES256 = "PBEWithHmacSHA256AndAES_256"
+	pbe3DES   = "PBEWithSHA1AndDESede"
+	pbeRC240  = "PBEWithSHA1AndRC2_40"
+)
+
+// MAC algorithms of PKCS12 keystores, named as Java names them.
+const (
+	MacAlgorithmSHA1   = "HmacPBESHA1"
+	MacAlgorithmSHA256 = "HmacPBESHA256"
+	MacAlgorithmSHA384 = "HmacPBESHA384"
+	MacAlgorithmSHA512 = "HmacPBESHA512"
+)
+
+// defaultMacIterations is the MAC iteration count of a custom profile when
+// mac_iterations is not set, the keytool default.
+const defaultMacIterations = 10000
+
+// pkcs12MacAlgorithms are the digests of the MAC algorithms.
+var pkcs12MacAlgorithms = map[string]struct {
+	oid  asn1.ObjectIdentifier
+	hash func() hash.Hash
+}{
+	MacAlgorithmSHA1:   {oidSHA1, sha1.New},
+	MacAlgorithmSHA256: {oidSHA256, sha256.New},
+	MacAlgorithmSHA384: {oidSHA384, sha512.New384},
+	MacAlgorithmSHA512: {oidSHA512, sha512.New},
+}
+
+// PKCS12Protection describes the algorithms protecting the entries and the
+// integrity of a PKCS12 keystore. keytool is told to use them with the
+// keystore.pkcs12 security properties, which Java supports since 8u301,
+// 11.0.12 and 17. The zero value leaves them to the keytool defaults.
+type PKCS12Protection struct {
+	KeyAlgorithm          string
+	KeyIterations         int
+	CertificateAlgorithm  string
+	CertificateIterations int
+	MacAlgorithm          string
+	MacIterations         int
+}
+
+// modernPKCS12Protection are the keytool defaults since Java 17, which Java
+// 8 before 8u301, OpenSSL 1.0 and older .NET versions cannot read.
+var modernPKCS12Protection = PKCS12Protection{
+	KeyAlgorithm:          pbeAES256,
+	KeyIterations:         10000,
+	CertificateAlgorithm:  pbeAES256,
+	CertificateIterations: 10000,
+	MacAlgorithm:          MacAlgorithmSHA256,
+	MacIterations:         10000,
+}
+
+// legacyPKCS12Protection are the keytool defaults of Java 8, as written
+// with keystore.pkcs12.legacy.
+var legacyPKCS12Protection = PKCS12Protection{
+	KeyAlgorithm:          pbe3DES,
+	KeyIterations:         50000,
+	CertificateAlgorithm:  pbeRC240,
+	CertificateIterations: 50000,
+	MacAlgorithm:          MacAlgorithmSHA1,
+	MacIterations:         100000,
+}
+
+// NewPKCS12Protection returns the protection of an encryption profile. The
+// custom profile encrypts entries as the modern one does, with the MAC
+// algorithm and iteration count given. An empty profile leaves the
+// protection to keytool.
+func NewPKCS12Protection(encryption, macAlgorithm string, macIterations int64) PKCS12Protection {
+	switch encryption {
+	case PKCS12EncryptionModern:
+		return modernPKCS12Protection
+	case PKCS12EncryptionLegacy:
+		return legacyPKCS12Protection
+	case PKCS12EncryptionCustom:
+		p := modernPKCS12Protection
+		p.MacAlgorithm = macAlgorithm
+		p.MacIterations = int(macIterations)
+		if p.MacIterations == 0 {
+			p.MacIterations = defaultMacIterations
+		}
+		return p
+	}
+	return PKCS12Protection{}
+}
+
+// args returns the keytool arguments that set the protection of the
+// PKCS12 keystores keytool writes.
+func (p PKCS12Protection) args() []string {
+	if p == (PKCS12Protection{}) {
+		return nil
+	}
+	property := func(name, value string) string {
+		return "-J-Dkeystore.pkcs12." + name + "=" + value
+	}
+	return []string{
+		property("keyProtectionAlgorithm", p.KeyAlgorithm),
+		property("keyPbeIterationCount", strconv.Itoa(p.KeyIterations)),
+		property("certProtectionAlgorithm", p.CertificateAlgorithm),
+		property("certPbeIterationCount", strconv.Itoa(p.CertificateIterations)),
+		property("macAlgorithm", p.MacAlgorithm),
+		property("macIterationCount", strconv.Itoa(p.MacIterations)),
+	}
+}
+
+// orModern returns p, or the modern protection for the zero value.
+func (p PKCS12Protection) orModern() PKCS12Protection {
+	if p == (PKCS12Protection{}) {
+		return modernPKCS12Protection
+	}
+	return p
+}
+
+// check verifies that the PKCS12 keystore in data is protected as p
+// describes. Java versions without the keystore.pkcs12 security properties
+// silently ignore them, so the keystores keytool writes are checked.
+func (p PKCS12Protection) check(data []byte) error {
+	if p == (PKCS12Protection{}) {
+		return nil
+	}
+	found, err := inspectPKCS12Protection(data)
+	if err != nil {
+		return err
+	}
+
+	var mismatches []string
+	compare := func(name, expected, actual string) {
+		if actual != "" && actual != expected {
+			mismatches = append(mismatches, fmt.Sprintf("%s is %s instead of %s", name, actual, expected))
+		}
+	}
+	compare("key encryption", p.KeyAlgorithm, found.KeyAlgorithm)
+	compare("key encryption iteration count", strconv.Itoa(p.KeyIterations), iterationsString(found.KeyIterations))
+	compare("certificate encryption", p.CertificateAlgorithm, found.CertificateAlgorithm)
+	compare("certificate encryption iteration count", strconv.Itoa(p.CertificateIterations), iterationsString(found.CertificateIterations))
+	compare("MAC algorithm", p.MacAlgorithm, found.MacAlgorithm)
+	compare("MAC iteration count", strconv.Itoa(p.MacIterations), iterationsString(found.MacIterations))
+	if len(mismatches) > 0 {
+		return fmt.Errorf("keytool did not protect the PKCS12 keystore as requested: %s. "+
+			"Setting the PKCS12 encryption requires keytool of Java 8u301, 11.0.12, 17 or later", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+func iterationsString(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// inspectPKCS12Protection reads the algorithms protecting a PKCS12
+// keystore without decrypting it. Algorithms of entries the keystore does
+// not hold are empty.
+func inspectPKCS12Protection(data []byte) (PKCS12Protection, error) {
+	var p PKCS12Protection
+
+	pfx := new(pfxPdu)
+	if err := unmarshalDER(data, pfx); err != nil {
+		return p, fmt.Errorf("error reading PKCS12 data: %w", err)
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return p, errors.New("only password integrity protected PKCS12 files are supported")
+	}
+	if oid := pfx.MacData.Mac.Algorithm.Algorithm; len(oid) != 0 {
+		p.MacAlgorithm = oid.String()
+		for name, alg := range pkcs12MacAlgorithms {
+			if alg.oid.Equal(oid) {
+				p.MacAlgorithm = name
+			}
+		}
+		p.MacIterations = pfx.MacData.Iterations
+	}
+
+	var authSafeData []byte
+	if err := unmarshalDER(pfx.AuthSafe.Content.Bytes, &authSafeData); err != nil {
+		return p, fmt.Errorf("error reading PKCS12 authenticated safe: %w", err)
+	}
+	var authenticatedSafe []contentInfo
+	if err := unmarshalDER(authSafeData, &authenticatedSafe); err != nil {
+		return p, fmt.Errorf("error reading PKCS12 authenticated safe: %w", err)
+	}
+
+	for _, ci := range authenticatedSafe {
+		switch {
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if err := unmarshalDER(ci.Content.Bytes, &ed); err != nil {
+				return p, err
+			}
+			p.CertificateAlgorithm, p.CertificateIterations = pbeAlgorithmName(ed.EncryptedContentInfo.ContentEncryptionAlgorithm)
+
+		case ci.ContentType.Equal(oidDataContentType):
+			var content []byte
+			if err := unmarshalDER(ci.Content.Bytes, &content); err != nil {
+				return p, err
+			}
+			var safeContents []safeBag
+			if err := unmarshalDER(content, &safeContents); err != nil {
+				return p, fmt.Errorf("error reading PKCS12 safe contents: %w", err)
+			}
+			for _, sb := range safeContents {
+				if !sb.Id.Equal(oidPKCS8ShroudedKeyBag) {
+					continue
+				}
+				var key encryptedPrivateKeyInfo
+				if err := unmarshalDER(sb.Value.Bytes, &key); err != nil {
+					return p, fmt.Errorf("error reading PKCS8 shrouded key bag: %w", err)
+				}
+				p.KeyAlgorithm, p.KeyIterations = pbeAlgorithmName(key.Algorithm)
+			}
+		}
+	}
+	return p, nil
+}
+
+// pbeAlgorithmName returns the Java name and the iteration count of a
+// password based encryption algorithm, or its OID when Java has no name
+// for it.
+func pbeAlgorithmName(alg pkix.AlgorithmIdentifier) (string, int) {
+	switch {
+	case alg.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC), alg.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC), alg.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC):
+		var params pbeParams
+		_ = unmarshalDER(alg.Parameters.FullBytes, &params)
+		name := pbe3DES
+		switch {
+		case alg.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+			name = pbeRC240
+		case alg.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC):
+			name = "PBEWithSHA1AndRC2_128"
+		}
+		return name, params.Iterations
+
+	case alg.Algorithm.Equal(oidPBES2):
+		var params pbes2Params
+		var kdf pbkdf2Params
+		if unmarshalDER(alg.Parameters.FullBytes, &params) != nil || unmarshalDER(params.Kdf.Parameters.FullBytes, &kdf) != nil {
+			return alg.Algorithm.String(), 0
+		}
+		if kdf.Prf.Algorithm.Equal(oidHmacWithSHA256) && params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+			return pbeAES256, kdf.Iterations
+		}
+		return fmt.Sprintf("PBES2 with %s and %s", kdf.Prf.Algorithm, params.EncryptionScheme.Algorithm), kdf.Iterations
+	}
+	return alg.Algorithm.String(), 0
+}
@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var oidSignedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// signedData is the SignedData of RFC 2315. A degenerate SignedData has no
+// content and no signers, and only carries certificates, as .p7b files do.
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue   `asn1:"tag:0,optional"`
+	CRLs             asn1.RawValue   `asn1:"tag:1,optional"`
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+// pkcs7PEMTypes are the PEM block types of a PKCS#7 bundle. OpenSSL writes
+// PKCS7, and some Windows tools PKCS #7 SIGNED DATA.
+var pkcs7PEMTypes = []string{"PKCS7", "PKCS #7 SIGNED DATA"}
+
+// maxBERDepth bounds the nesting of a BER encoding read by berToDER.
+const maxBERDepth = 32
+
+// ParseCertificatesPKCS7 parses the certificates of a PKCS#7 bundle, such
+// as a .p7b file, given as base64 encoded DER or BER, or as a PEM PKCS7
+// block. Enterprise CAs such as AD CS write BER with indefinite lengths.
+// The certificates are returned in the order of the bundle.
+func ParseCertificatesPKCS7(s string) ([]*x509.Certificate, error) {
+	var der []byte
+	if strings.Contains(s, "-----BEGIN") {
+		block, rest := pem.Decode([]byte(s))
+		if block == nil {
+			return nil, errors.New("no PEM block found")
+		}
+		if !slices.Contains(pkcs7PEMTypes, block.Type) {
+			return nil, fmt.Errorf("unexpected PEM block of type %q, expected PKCS7", block.Type)
+		}
+		if len(strings.TrimSpace(string(rest))) != 0 {
+			return nil, errors.New("trailing data after the PKCS7 block")
+		}
+		der = block.Bytes
+	} else {
+		var err error
+		der, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding base64 PKCS#7 bundle: %w", err)
+		}
+	}
+
+	der, err := berToDER(der)
+	if err != nil {
+		return nil, fmt.Errorf("error reading PKCS#7 bundle: %w", err)
+	}
+	var ci contentInfo
+	if err := unmarshalDER(der, &ci); err != nil {
+		return nil, fmt.Errorf("error reading PKCS#7 content info: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedDataContentType) {
+		return nil, fmt.Errorf("unsupported PKCS#7 content type %s, expected signed data", ci.ContentType)
+	}
+	var sd signedData
+	if err := unmarshalDER(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("error reading PKCS#7 signed data: %w", err)
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error reading PKCS#7 certificates: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("PKCS#7 bundle holds no certificate")
+	}
+	return certs, nil
+}
+
+// EncodeCertificatesPKCS7 encodes certs as the DER of a degenerate
+// SignedData, as openssl crl2pkcs7 -nocrl writes it. The certificates keep
+// their order instead of being sorted as DER sets are, so that a chain
+// stays leaf first.
+func EncodeCertificatesPKCS7(certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+	content, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      contentInfo{ContentType: oidDataContentType},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      []asn1.RawValue{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedDataContentType,
+		Content:     explicitTag(content),
+	})
+}
+
+// berToDER re-encodes BER as the DER that encoding/asn1 reads: indefinite
+// lengths become definite and constructed OCTET STRINGs are joined into
+// primitive ones. DER input is returned unchanged.
+func berToDER(ber []byte) ([]byte, error) {
+	tag, content, rest, err := berElement(ber, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after ASN.1 structure")
+	}
+	return derElement(tag, content), nil
+}
+
+// berElement reads the element at the start of b, returning its identifier
+// octets, its content re-encoded as DER and the bytes after it.
+func berElement(b []byte, depth int) (tag, content, rest []byte, err error) {
+	if depth > maxBERDepth {
+		return nil, nil, nil, errors.New("ASN.1 structure nested too deeply")
+	}
+
+	// Identifier octets, with the high tag number form.
+	n := 1
+	if len(b) < 2 {
+		return nil, nil, nil, errors.New("truncated ASN.1 element")
+	}
+	if b[0]&0x1f == 0x1f {
+		for n < len(b) && b[n]&0x80 != 0 {
+			n++
+		}
+		n++
+	}
+	if n >= len(b) {
+		return nil, nil, nil, errors.New("truncated ASN.1 element")
+	}
+	tag, b = b[:n], b[n:]
+	constructed := tag[0]&0x20 != 0
+
+	// Length octets. A length of 0x80 is indefinite, and the content ends
+	// with two zero octets.
+	indefinite := b[0] == 0x80
+	length := int(b[0])
+	b = b[1:]
+	switch {
+	case indefinite:
+		if !constructed {
+			return nil, nil, nil, errors.New("indefinite length of a primitive ASN.1 element")
+		}
+	case length > 0x80:
+		size := length & 0x7f
+		if size > 4 || size > len(b) {
+			return nil, nil, nil, errors.New("invalid ASN.1 length")
+		}
+		length = 0
+		for _, c := range b[:size] {
+			length = length<<8 | int(c)
+		}
+		b = b[size:]
+	}
+	if !indefinite && (length < 0 || length > len(b)) {
+		return nil, nil, nil, errors.New("truncated ASN.1 element")
+	}
+
+	if !constructed {
+		return tag, b[:length], b[length:], nil
+	}
+
+	children := b
+	if !indefinite {
+		children, rest = b[:length], b[length:]
+	}
+	// A constructed OCTET STRING is the concatenation of its segments.
+	octetString := len(tag) == 1 && tag[0] == 0x24
+	for {
+		if indefinite {
+			if len(children) < 2 {
+				return nil, nil, nil, errors.New("missing end of ASN.1 indefinite length content")
+			}
+			if children[0] == 0 && children[1] == 0 {
+				rest = children[2:]
+				break
+			}
+		} else if len(children) == 0 {
+			break
+		}
+		childTag, childContent, childRest, err := berElement(children, depth+1)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if octetString {
+			if len(childTag) != 1 || childTag[0]&^0x20 != 0x04 {
+				return nil, nil, nil, errors.New("invalid segment of a constructed OCTET STRING")
+			}
+			content = append(content, childContent...)
+		} else {
+			content = append(content, derElement(childTag, childContent)...)
+		}
+		children = childRest
+	}
+	if octetString {
+		tag = []byte{0x04}
+	}
+	return tag, content, rest, nil
+}
+
+// derElement encodes an element with a definite length in its shortest
+// form.
+func derElement(tag, content []byte) []byte {
+	out := slices.Clone(tag)
+	switch n := len(content); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	default:
+		var length []byte
+		for ; n > 0; n >>= 8 {
+			length = append([]byte{byte(n)}, length...)
+		}
+		out = append(out, 0x80|byte(len(length)))
+		out = append(out, length...)
+	}
+	return append(out, content...)
+}
@@ -0,0 +1,227 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCertificatesPKCS7RoundTrip(t *testing.T) {
+	certs, err := ParseCertificatesPEM(testSelfSignedCertificatePEM(t, "Leaf") + testSelfSignedCertificatePEM(t, "Root CA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := EncodeCertificatesPKCS7(certs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := base64.StdEncoding.EncodeToString(der)
+	inputs := map[string]string{
+		"base64":          b64,
+		"wrapped":         b64[:40] + "\n" + b64[40:] + "\n",
+		"PEM":             string(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der})),
+		"PEM signed data": string(pem.EncodeToMemory(&pem.Block{Type: "PKCS #7 SIGNED DATA", Bytes: der})),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseCertificatesPKCS7(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed) != len(certs) {
+				t.Fatalf("expected %d certificates, got %d", len(certs), len(parsed))
+			}
+			for i := range certs {
+				if !parsed[i].Equal(certs[i]) {
+					t.Errorf("expected certificate %d to keep its position", i)
+				}
+			}
+		})
+	}
+}
+
+// testBERCertificatesPKCS7 encodes certs as a degenerate SignedData the way
+// AD CS writes certnew.p7b: every structure around the certificates has an
+// indefinite length, and the certificates themselves are DER.
+func testBERCertificatesPKCS7(t *testing.T, certs []*x509.Certificate) []byte {
+	t.Helper()
+
+	indefinite := func(tag byte, content ...[]byte) []byte {
+		out := []byte{tag, 0x80}
+		for _, c := range content {
+			out = append(out, c...)
+		}
+		return append(out, 0x00, 0x00)
+	}
+	oid := func(oid asn1.ObjectIdentifier) []byte {
+		b, err := asn1.Marshal(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	var raw [][]byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw)
+	}
+	return indefinite(0x30, // ContentInfo
+		oid(oidSignedDataContentType),
+		indefinite(0xa0, // [0] EXPLICIT
+			indefinite(0x30, // SignedData
+				[]byte{0x02, 0x01, 0x01}, // version
+				[]byte{0x31, 0x00},       // digestAlgorithms
+				indefinite(0x30, oid(oidDataContentType)),
+				indefinite(0xa0, raw...), // certificates
+				[]byte{0x31, 0x00},       // signerInfos
+			),
+		),
+	)
+}
+
+func TestParseCertificatesPKCS7BER(t *testing.T) {
+	certs, err := ParseCertificatesPEM(testSelfSignedCertificatePEM(t, "Leaf") + testSelfSignedCertificatePEM(t, "Root CA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ber := testBERCertificatesPKCS7(t, certs)
+
+	inputs := map[string]string{
+		"base64": base64.StdEncoding.EncodeToString(ber),
+		"PEM":    string(pem.EncodeToMemory(&pem.Block{Type: "PKCS #7 SIGNED DATA", Bytes: ber})),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseCertificatesPKCS7(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if EncodeCertificatesPEM(parsed) != EncodeCertificatesPEM(certs) {
+				t.Error("expected the certificates of the BER bundle, in order")
+			}
+		})
+	}
+}
+
+func TestBERToDER(t *testing.T) {
+	tests := map[string]struct {
+		ber, der string
+	}{
+		"DER":                      {ber: "3003020101", der: "3003020101"},
+		"indefinite length":        {ber: "30800201010000", der: "3003020101"},
+		"nested indefinite length": {ber: "3080 3080020101 0000 3100 0000", der: "3007" + "3003020101" + "3100"},
+		"non-minimal length":       {ber: "3081030201 01", der: "3003020101"},
+		"constructed OCTET STRING": {ber: "24800401610403626364 0000", der: "0404" + "61626364"},
+		"high tag number":          {ber: "bf1f80020101 0000", der: "bf1f03020101"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ber, err := hex.DecodeString(strings.ReplaceAll(test.ber, " ", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			der, err := berToDER(ber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(der) != test.der {
+				t.Errorf("expected %s, got %x", test.der, der)
+			}
+		})
+	}
+
+	for name, ber := range map[string]string{
+		"truncated":              "3005020101",
+		"missing end of content": "3080020101",
+		"indefinite primitive":   "04800000",
+		"trailing data":          "300302010100",
+		"invalid OCTET STRING":   "2480020101 0000",
+		"too deeply nested":      strings.Repeat("3080", maxBERDepth+2) + strings.Repeat("0000", maxBERDepth+2),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(strings.ReplaceAll(ber, " ", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := berToDER(b); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseCertificatesPKCS7Invalid(t *testing.T) {
+	empty, err := EncodeCertificatesPKCS7(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := map[string]string{
+		"not base64":     "not a bundle",
+		"not PKCS#7":     base64.StdEncoding.EncodeToString([]byte{0x30, 0x00}),
+		"no certificate": base64.StdEncoding.EncodeToString(empty),
+		"certificate":    testSelfSignedCertificatePEM(t, "Root CA"),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseCertificatesPKCS7(input); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCertificatesPKCS7OpenSSL(t *testing.T) {
+	testRequireTool(t, "openssl")
+	dir := t.TempDir()
+
+	chain := testSelfSignedCertificatePEM(t, "Leaf") + testSelfSignedCertificatePEM(t, "Root CA")
+	if err := os.WriteFile(filepath.Join(dir, "chain.pem"), []byte(chain), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("openssl", "crl2pkcs7", "-nocrl", "-certfile", filepath.Join(dir, "chain.pem"), "-outform", "DER", "-out", filepath.Join(dir, "chain.p7b")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl crl2pkcs7: %s\n%s", err, out)
+	}
+	p7b, err := os.ReadFile(filepath.Join(dir, "chain.p7b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := ParseCertificatesPKCS7(base64.StdEncoding.EncodeToString(p7b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EncodeCertificatesPEM(certs) != chain {
+		t.Error("expected the chain written by OpenSSL")
+	}
+	der, err := EncodeCertificatesPKCS7(certs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(der) != string(p7b) {
+		t.Error("expected the bundle OpenSSL writes")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "ours.p7b"), der, 0600); err != nil {
+		t.Fatal(err)
+	}
+	out, err = exec.Command("openssl", "pkcs7", "-inform", "DER", "-in", filepath.Join(dir, "ours.p7b"), "-print_certs").CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl pkcs7: %s\n%s", err, out)
+	}
+	if strings.Count(string(out), "BEGIN CERTIFICATE") != 2 {
+		t.Errorf("expected OpenSSL to read both certificates, got\n%s", out)
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// KeystoreContentChanged reports whether an in-place update from prior to
+// planned changes the bytes of the keystore, as opposed to only its local
+// copy.
+func KeystoreContentChanged(planned, prior KeystoreResourceModel) bool {
+	return !planned.Password.Equal(prior.Password) ||
+		!planned.PasswordWoVersion.Equal(prior.PasswordWoVersion) ||
+		!planned.SignedCertificate.Equal(prior.SignedCertificate) ||
+		!planned.CaChain.Equal(prior.CaChain) ||
+		!planned.CaChainP7b.Equal(prior.CaChainP7b) ||
+		!planned.Pkcs12Encryption.Equal(prior.Pkcs12Encryption) ||
+		!planned.MacAlgorithm.Equal(prior.MacAlgorithm) ||
+		!planned.MacIterations.Equal(prior.MacIterations) ||
+		!planned.TrustedCertificates.Equal(prior.TrustedCertificates)
+}
+
+// keystorePlanAndState reads the whole planned and prior jks_keystore for
+// attribute plan modifiers that depend on other attributes.
+func keystorePlanAndState(ctx context.Context, plan tfsdk.Plan, state tfsdk.State) (KeystoreResourceModel, KeystoreResourceModel, diag.Diagnostics) {
+	var planned, prior KeystoreResourceModel
+	var diags diag.Diagnostics
+	diags.Append(plan.Get(ctx, &planned)...)
+	diags.Append(state.Get(ctx, &prior)...)
+	return planned, prior, diags
+}
+
+// keepUnlessContentChanges plans an attribute derived from the keystore as
+// its prior value unless the keystore content changes, so that updating
+// only the local copy settings does not re-encrypt the keystore. With
+// nullUnlessStored the attribute is planned null when store_in_state is
+// false.
+type keepUnlessContentChanges struct {
+	nullUnlessStored bool
+}
+
+func (m keepUnlessContentChanges) Description(ctx context.Context) string {
+	return "Keeps the prior value unless an attribute affecting the keystore content changes."
+}
+
+func (m keepUnlessContentChanges) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m keepUnlessContentChanges) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if !req.PlanValue.IsUnknown() {
+		return
+	}
+
+	var planned KeystoreResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if m.nullUnlessStored && !planned.StoreInState.IsUnknown() && !planned.StoredInState() {
+		resp.PlanValue = types.StringNull()
+		return
+	}
+	if req.StateValue.IsNull() {
+		return
+	}
+
+	_, prior, diags := keystorePlanAndState(ctx, req.Plan, req.State)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || KeystoreContentChanged(planned, prior) {
+		return
+	}
+
+	resp.PlanValue = req.StateValue
+}
+
+// requirePreviousPassword reports a missing previous_password_wo when
+// password_wo_version changes on a keystore encrypted with password_wo. The
+// previous write-only password is not in the state, and is needed to
+// re-encrypt the keystore with the new one. A keystore of trusted
+// certificates is encoded again instead, which does not need it.
+type requirePreviousPassword struct{}
+
+func (m requirePreviousPassword) Description(ctx context.Context) string {
+	return "Requires previous_password_wo to rotate a write-only password."
+}
+
+func (m requirePreviousPassword) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m requirePreviousPassword) PlanModifyInt64(ctx context.Context, req planmodifier.Int64Request, resp *planmodifier.Int64Response) {
+	// Removing the version switches to password, which replaces the keystore.
+	if req.StateValue.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() || req.PlanValue.Equal(req.StateValue) {
+		return
+	}
+
+	var password, previous types.String
+	var trusted types.Map
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("password"), &password)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("trusted_certificates"), &trusted)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("previous_password_wo"), &previous)...)
+	if resp.Diagnostics.HasError() || !password.IsNull() || !previous.IsNull() || !trusted.IsNull() {
+		return
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		path.Root("previous_password_wo"),
+		"Missing previous password",
+		"password_wo_version changed, but previous_password_wo is not set. "+
+			"The keystore is encrypted with the previous password_wo, which is not kept in the Terraform state: set previous_password_wo to it to rotate the password.",
+	)
+}
+
+// nullKeyAlgorithmUnlessGenerated plans an unconfigured key_algorithm as
+// null for keystores without a generated key pair. A keystore moved from
+// tls_self_signed_cert holds a key it did not generate, so the RSA default
+// does not apply to it, and a configured key_algorithm is recorded without
+// replacing the keystore. A keystore of trusted_certificates has no key.
+type nullKeyAlgorithmUnlessGenerated struct{}
+
+func (m nullKeyAlgorithmUnlessGenerated) Description(ctx context.Context) string {
+	return "Keeps key_algorithm unset on a moved keystore or one of trusted certificates unless it is configured."
+}
+
+func (m nullKeyAlgorithmUnlessGenerated) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m nullKeyAlgorithmUnlessGenerated) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.Plan.Raw.IsNull() || !req.ConfigValue.IsNull() {
+		return
+	}
+
+	var trusted types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("trusted_certificates"), &trusted)...)
+	moved := !req.State.Raw.IsNull() && req.StateValue.IsNull()
+	if !trusted.IsNull() || moved {
+		resp.PlanValue = types.StringNull()
+	}
+}
+
+// signedCertificateOnCreateDetail explains why signed_certificate_pem is
+// rejected when the keystore is created.
+const signedCertificateOnCreateDetail = "signed_certificate_pem must carry the public key of the keystore's private key, which is generated when the keystore is created. " +
+	"Create the keystore without it, have its certificate_request_pem signed, and set signed_certificate_pem in a later apply."
+
+// rejectOnCreate reports a signed_certificate_pem configured for a keystore
+// that is yet to be created, as no certificate can match the key pair it
+// will generate.
+type rejectOnCreate struct{}
+
+func (m rejectOnCreate) Description(ctx context.Context) string {
+	return "Rejects a certificate reply for a keystore that is yet to be created."
+}
+
+func (m rejectOnCreate) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m rejectOnCreate) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if !req.State.Raw.IsNull() || req.Plan.Raw.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+		return
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, "Certificate reply on create", signedCertificateOnCreateDetail)
+}
+
+// destinationDigestFromFile plans destination_sha256 as the digest of the
+// keystore that will be on disk. Read records the digest actually found, so
+// a local copy that was changed or deleted shows up as a difference that is
+// repaired by rewriting the file from state. When the keystore is not kept
+// in state, Read removes the resource instead and the digest is only
+// unknown when the keystore content changes.
+type destinationDigestFromFile struct{}
+
+func (m destinationDigestFromFile) Description(ctx context.Context) string {
+	return "Plans the digest of the keystore written to destination_path."
+}
+
+func (m destinationDigestFromFile) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m destinationDigestFromFile) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	var planned KeystoreResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case planned.DestinationPath.IsNull():
+		resp.PlanValue = types.StringNull()
+	case planned.DestinationPath.IsUnknown(), planned.StoreInState.IsUnknown():
+		resp.PlanValue = types.StringUnknown()
+	case !planned.StoredInState():
+		if req.StateValue.IsNull() {
+			resp.PlanValue = types.StringUnknown()
+			return
+		}
+		_, prior, diags := keystorePlanAndState(ctx, req.Plan, req.State)
+		resp.Diagnostics.Append(diags...)
+		if KeystoreContentChanged(planned, prior) {
+			resp.PlanValue = types.StringUnknown()
+			return
+		}
+		resp.PlanValue = req.StateValue
+	case planned.File.IsUnknown():
+		resp.PlanValue = types.StringUnknown()
+	default:
+		decoded, err := base64.StdEncoding.DecodeString(planned.File.ValueString())
+		if err != nil {
+			resp.PlanValue = types.StringUnknown()
+			return
+		}
+		resp.PlanValue = types.StringValue(SHA256Hex(decoded))
+	}
+}
+
+// truststoreFingerprintsFromSources plans fingerprints_sha256 from the
+// certificates the truststore will hold, reading the included files, so
+// that a changed cacerts file or CA bundle shows up as a difference.
+type truststoreFingerprintsFromSources struct{}
+
+func (m truststoreFingerprintsFromSources) Description(ctx context.Context) string {
+	return "Plans the fingerprints of the certificates the truststore is built from."
+}
+
+func (m truststoreFingerprintsFromSources) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m truststoreFingerprintsFromSources) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	var planned TruststoreResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !planned.ContentKnown() {
+		resp.PlanValue = types.MapUnknown(types.StringType)
+		return
+	}
+
+	entries, diags := planned.Entries(ctx)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+	resp.PlanValue = Fingerprints(entries)
+}
+
+// keepTruststoreUnlessContentChanges plans the truststore file as its prior
+// value unless its password or certificates change, including those of the
+// included files.
+type keepTruststoreUnlessContentChanges struct{}
+
+func (m keepTruststoreUnlessContentChanges) Description(ctx context.Context) string {
+	return "Keeps the truststore unless its password or certificates change."
+}
+
+func (m keepTruststoreUnlessContentChanges) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m keepTruststoreUnlessContentChanges) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var planned, prior TruststoreResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !planned.ContentKnown() || !planned.Password.Equal(prior.Password) {
+		resp.PlanValue = types.StringUnknown()
+		return
+	}
+
+	entries, diags := planned.Entries(ctx)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+	if Fingerprints(entries).Equal(prior.FingerprintsSha256) {
+		resp.PlanValue = req.StateValue
+		return
+	}
+	resp.PlanValue = types.StringUnknown()
+}
+
+// keystoreEntryFromConfig plans entry_type and fingerprint_sha256 of a
+// keystore entry from its configured certificate and private key. Read
+// records the entry actually found in the keystore file, so an entry
+// replaced outside of Terraform shows up as a difference and is written
+// again.
+type keystoreEntryFromConfig struct{}
+
+func (m keystoreEntryFromConfig) Description(ctx context.Context) string {
+	return "Plans the type and fingerprint of the configured keystore entry."
+}
+
+func (m keystoreEntryFromConfig) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m keystoreEntryFromConfig) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var planned KeystoreEntryResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	entryType, fingerprint := planned.ExpectedEntry()
+	if req.Path.Equal(path.Root("entry_type")) {
+		resp.PlanValue = entryType
+	} else {
+		resp.PlanValue = fingerprint
+	}
+}
+
+// sourceStoreTypeFromKeystore plans an unset source_store_type of a
+// jks_keystore_conversion as the store type detected from source_keystore.
+// A keystore whose type cannot be detected is reported by the conversion.
+type sourceStoreTypeFromKeystore struct{}
+
+func (m sourceStoreTypeFromKeystore) Description(ctx context.Context) string {
+	return "Plans the store type detected from source_keystore unless configured."
+}
+
+func (m sourceStoreTypeFromKeystore) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m sourceStoreTypeFromKeystore) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.Plan.Raw.IsNull() || !req.ConfigValue.IsNull() {
+		return
+	}
+
+	var keystore types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("source_keystore"), &keystore)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if keystore.IsUnknown() {
+		resp.PlanValue = types.StringUnknown()
+		return
+	}
+	storeType, err := detectBase64StoreType(keystore.ValueString())
+	if err != nil {
+		resp.PlanValue = types.StringUnknown()
+		return
+	}
+	resp.PlanValue = types.StringValue(storeType)
+}
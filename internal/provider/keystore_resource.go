@@ -5,19 +5,41 @@ package provider
 
 import (
 	"context"
-	"reflect"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &KeystoreResource{}
+var _ resource.ResourceWithValidateConfig = &KeystoreResource{}
+var _ resource.ResourceWithUpgradeState = &KeystoreResource{}
+var _ resource.ResourceWithConfigValidators = &KeystoreResource{}
 
 func NewKeystoreResource() resource.Resource {
 	return &KeystoreResource{}
@@ -25,13 +47,45 @@ func NewKeystoreResource() resource.Resource {
 
 // KeystoreResource defines the resource implementation.
 type KeystoreResource struct {
+	runner KeytoolRunner
 }
 
 // KeystoreResourceModel describes the resource data model.
 type KeystoreResourceModel struct {
-	Id                 types.String `tfsdk:"id"`
-	Password           types.String `tfsdk:"password"`
-	File               types.String `tfsdk:"file"`
+	Id                  types.String `tfsdk:"id"`
+	Password            types.String `tfsdk:"password"`
+	PasswordWo          types.String `tfsdk:"password_wo"`
+	PasswordWoVersion   types.Int64  `tfsdk:"password_wo_version"`
+	PreviousPasswordWo  types.String `tfsdk:"previous_password_wo"`
+	File                types.String `tfsdk:"file"`
+	Subject             types.Object `tfsdk:"subject"`
+	KeyAlgorithm        types.String `tfsdk:"key_algorithm"`
+	SignatureAlgorithm  types.String `tfsdk:"signature_algorithm"`
+	Pkcs12Encryption    types.String `tfsdk:"pkcs12_encryption"`
+	MacAlgorithm        types.String `tfsdk:"mac_algorithm"`
+	MacIterations       types.Int64  `tfsdk:"mac_iterations"`
+	SignedCertificate   types.String `tfsdk:"signed_certificate_pem"`
+	CaChain             types.String `tfsdk:"ca_chain_pem"`
+	CaChainP7b          types.String `tfsdk:"ca_chain_p7b"`
+	TrustedCertificates types.Map    `tfsdk:"trusted_certificates"`
+	DestinationPath     types.String `tfsdk:"destination_path"`
+	FilePermission      types.String `tfsdk:"file_permission"`
+	FileOwner           types.String `tfsdk:"file_owner"`
+	FileGroup           types.String `tfsdk:"file_group"`
+	DestinationSha256   types.String `tfsdk:"destination_sha256"`
+	StoreInState        types.Bool   `tfsdk:"store_in_state"`
+
+	CertificateSubject           types.String `tfsdk:"certificate_subject"`
+	CertificateFingerprintSha256 types.String `tfsdk:"certificate_fingerprint_sha256"`
+	CertificateNotAfter          types.String `tfsdk:"certificate_not_after"`
+	CertificatePem               types.String `tfsdk:"certificate_pem"`
+	CertificateChainPem          types.String `tfsdk:"certificate_chain_pem"`
+	CertificateChainP7b          types.String `tfsdk:"certificate_chain_p7b"`
+	CertificateRequestPem        types.String `tfsdk:"certificate_request_pem"`
+}
+
+// SubjectModel describes the subject attribute of jks_keystore.
+type SubjectModel struct {
 	CommonName         types.String `tfsdk:"common_name"`
 	Organization       types.String `tfsdk:"organization"`
 	OrganizationalUnit types.String `tfsdk:"organizational_unit"`
@@ -40,21 +94,258 @@ type KeystoreResourceModel struct {
 	Country            types.String `tfsdk:"country"`
 }
 
+var subjectAttrTypes = map[string]attr.Type{
+	"common_name":         types.StringType,
+	"organization":        types.StringType,
+	"organizational_unit": types.StringType,
+	"locality":            types.StringType,
+	"state":               types.StringType,
+	"country":             types.StringType,
+}
+
+// KeystorePassword returns the password the keystore is encrypted with,
+// which is password_wo when set. Write-only values are only available in
+// the configuration, so callers must first read password_wo from there.
+func (r KeystoreResourceModel) KeystorePassword() string {
+	if !r.PasswordWo.IsNull() {
+		return r.PasswordWo.ValueString()
+	}
+	return r.Password.ValueString()
+}
+
+// PriorKeystorePassword returns the password the keystore of the prior
+// state is encrypted with. A write-only password is not kept in the state:
+// it is previous_password_wo when password_wo_version changes, and
+// password_wo otherwise. Callers must first read both from the
+// configuration.
+func (r KeystoreResourceModel) PriorKeystorePassword(prior KeystoreResourceModel) string {
+	switch {
+	case !prior.Password.IsNull():
+		return prior.Password.ValueString()
+	case !r.PasswordWoVersion.Equal(prior.PasswordWoVersion):
+		return r.PreviousPasswordWo.ValueString()
+	default:
+		return r.KeystorePassword()
+	}
+}
+
+// errorPaths returns the attributes keystore errors are reported on.
+func (r KeystoreResourceModel) errorPaths() keystoreErrorPaths {
+	password := path.Root("password")
+	if !r.PasswordWo.IsNull() {
+		password = path.Root("password_wo")
+	}
+	return keystoreErrorPaths{
+		ErrIncorrectPassword:    password,
+		ErrUnsupportedAlgorithm: path.Root("key_algorithm"),
+		ErrCertificateMismatch:  path.Root("signed_certificate_pem"),
+	}
+}
+
+// CaChainPEM returns the CA chain installed with signed_certificate_pem as
+// PEM, converting ca_chain_p7b when it is set instead of ca_chain_pem.
+func (r KeystoreResourceModel) CaChainPEM() (string, error) {
+	if r.CaChainP7b.IsNull() {
+		return r.CaChain.ValueString(), nil
+	}
+	certs, err := ParseCertificatesPKCS7(r.CaChainP7b.ValueString())
+	if err != nil {
+		return "", fmt.Errorf("error parsing ca_chain_p7b\nError: %s", err)
+	}
+	return EncodeCertificatesPEM(certs), nil
+}
+
+// SubjectDistinguishedName converts a subject attribute. Unset fields, or
+// all of them when subject is null, are empty.
+func SubjectDistinguishedName(subject types.Object) DistinguishedName {
+	attributes := subject.Attributes()
+	value := func(name string) string {
+		s, _ := attributes[name].(types.String)
+		return s.ValueString()
+	}
+	return DistinguishedName{
+		CommonName:         value("common_name"),
+		Organization:       value("organization"),
+		OrganizationalUnit: value("organizational_unit"),
+		Locality:           value("locality"),
+		State:              value("state"),
+		Country:            value("country"),
+	}
+}
+
 func (r KeystoreResourceModel) ToKeystoreModel() KeystoreModel {
 	return KeystoreModel{
-		Password: r.Password.ValueString(),
-		File:     r.File.ValueString(),
-		DistinguishedName: DistinguishedName{
-			CommonName:         r.CommonName.ValueString(),
-			Organization:       r.Organization.ValueString(),
-			OrganizationalUnit: r.OrganizationalUnit.ValueString(),
-			Locality:           r.Locality.ValueString(),
-			State:              r.State.ValueString(),
-			Country:            r.Country.ValueString(),
+		Password:          r.KeystorePassword(),
+		File:              r.File.ValueString(),
+		DistinguishedName: SubjectDistinguishedName(r.Subject),
+		KeyPair: KeyPair{
+			Algorithm:          r.KeyAlgorithm.ValueString(),
+			SignatureAlgorithm: r.SignatureAlgorithm.ValueString(),
 		},
+		Protection: NewPKCS12Protection(r.Pkcs12Encryption.ValueString(), r.MacAlgorithm.ValueString(), r.MacIterations.ValueInt64()),
 	}
 }
 
+// HasKeyPair reports whether the keystore holds a generated key pair, and
+// not only the certificates of trusted_certificates.
+func (r KeystoreResourceModel) HasKeyPair() bool {
+	return r.TrustedCertificates.IsNull()
+}
+
+// EncodeTrustedCertificates encodes the trusted_certificates of a keystore
+// without a key pair, which the provider does itself, without keytool.
+// Without a password the keystore has no integrity MAC and its
+// certificates are not encrypted.
+func (r KeystoreResourceModel) EncodeTrustedCertificates(ctx context.Context) (string, diag.Diagnostics) {
+	entries, diags := ParseTrustedCertificates(ctx, r.TrustedCertificates, path.Root("trusted_certificates"))
+	if diags.HasError() {
+		return "", diags
+	}
+
+	password := r.KeystorePassword()
+	ctx = keystoreLogContext(ctx, password)
+
+	aliases := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		aliases = append(aliases, entry.Alias)
+	}
+
+	start := time.Now()
+	data, err := encodePKCS12(entries, password, r.ToKeystoreModel().Protection)
+	logKeystoreOperation(ctx, "encode", StoreTypePKCS12, aliases, start, err)
+	if err != nil {
+		addKeystoreError(&diags, "encode", fmt.Errorf("error encoding keystore\nError: %w", err), r.errorPaths())
+		return "", diags
+	}
+	return base64.StdEncoding.EncodeToString(data), diags
+}
+
+// Destination describes the local copy of the keystore, if any.
+func (r KeystoreResourceModel) Destination() (LocalFile, bool) {
+	return LocalFile{
+		Path:       r.DestinationPath.ValueString(),
+		Permission: r.FilePermission.ValueString(),
+		Owner:      r.FileOwner.ValueString(),
+		Group:      r.FileGroup.ValueString(),
+	}, !r.DestinationPath.IsNull()
+}
+
+// StoredInState reports whether the keystore itself is kept in state.
+// States written before store_in_state existed have it null.
+func (r KeystoreResourceModel) StoredInState() bool {
+	return r.StoreInState.IsNull() || r.StoreInState.ValueBool()
+}
+
+// LoadKeystore returns the base64 encoded keystore, read from state or,
+// when it is not stored there, from destination_path after checking that
+// the file still matches the recorded digest.
+func (r KeystoreResourceModel) LoadKeystore() (string, error) {
+	if r.StoredInState() {
+		return r.File.ValueString(), nil
+	}
+
+	dest, _ := r.Destination()
+	data, err := os.ReadFile(dest.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading keystore from destination_path\nError: %s", err)
+	}
+	if digest := SHA256Hex(data); digest != r.DestinationSha256.ValueString() {
+		return "", fmt.Errorf("keystore at %s was modified outside of Terraform: its SHA-256 digest is %s, expected %s", dest.Path, digest, r.DestinationSha256.ValueString())
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// SetKeystore records the base64 encoded keystore in the model: in the file
+// attribute unless store_in_state is false, at destination_path when set,
+// and as a digest and certificate metadata. The copy written for the prior
+// state is removed if destination_path moved.
+func (r *KeystoreResourceModel) SetKeystore(ctx context.Context, b64File string, prior *KeystoreResourceModel) error {
+	decoded, err := base64.StdEncoding.DecodeString(b64File)
+	if err != nil {
+		return fmt.Errorf("error decoding base64 keystore file\nError: %s", err)
+	}
+
+	if prior != nil {
+		if old, ok := prior.Destination(); ok && old.Path != r.DestinationPath.ValueString() {
+			if err := old.Remove(); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.File = types.StringNull()
+	if r.StoredInState() {
+		r.File = types.StringValue(b64File)
+	}
+
+	r.DestinationSha256 = types.StringNull()
+	if dest, ok := r.Destination(); ok {
+		if err := dest.Write(decoded); err != nil {
+			return err
+		}
+		r.DestinationSha256 = types.StringValue(SHA256Hex(decoded))
+	} else if !r.StoredInState() {
+		return fmt.Errorf("destination_path is required when store_in_state is false")
+	}
+
+	// A keystore of trusted certificates has no key entry to describe.
+	if !r.HasKeyPair() {
+		r.CertificateSubject = types.StringNull()
+		r.CertificateFingerprintSha256 = types.StringNull()
+		r.CertificateNotAfter = types.StringNull()
+		r.CertificatePem = types.StringNull()
+		r.CertificateChainPem = types.StringNull()
+		r.CertificateChainP7b = types.StringNull()
+		r.CertificateRequestPem = types.StringNull()
+		return nil
+	}
+
+	// The certificate attributes are planned as their prior values unless
+	// the keystore content changes, and the keystore may not be readable
+	// with a password_wo changed without password_wo_version.
+	if prior != nil && !KeystoreContentChanged(*r, *prior) {
+		return nil
+	}
+	return r.SetCertificate(ctx, decoded)
+}
+
+// SetCertificateRequest records the certificate request for the key entry
+// of the keystore of m.
+func (r *KeystoreResourceModel) SetCertificateRequest(ctx context.Context, m KeystoreModel) error {
+	request, err := m.CertificateRequestPEM(ctx)
+	if err != nil {
+		return err
+	}
+	r.CertificateRequestPem = types.StringValue(request)
+	return nil
+}
+
+// SetCertificate records the certificate attributes of the key entry of the
+// decoded keystore.
+func (r *KeystoreResourceModel) SetCertificate(ctx context.Context, decoded []byte) error {
+	ks, err := decodeKeystore(keystoreLogContext(ctx, r.KeystorePassword()), decoded, StoreTypePKCS12, r.KeystorePassword())
+	if err != nil {
+		return fmt.Errorf("error decoding keystore\n%w", err)
+	}
+	entry, ok := ks.Entry(KeyAlias)
+	if !ok || len(entry.Certificates) == 0 {
+		return newKeystoreError(ErrAliasNotFound, "keystore has no certificate under the alias %q", KeyAlias)
+	}
+	cert := entry.Certificates[0]
+	r.CertificateSubject = types.StringValue(cert.Subject.String())
+	r.CertificateFingerprintSha256 = types.StringValue(SHA256Hex(cert.Raw))
+	r.CertificateNotAfter = types.StringValue(cert.NotAfter.UTC().Format(time.RFC3339))
+	r.CertificatePem = types.StringValue(EncodeCertificatesPEM(entry.Certificates[:1]))
+	r.CertificateChainPem = types.StringValue(EncodeCertificatesPEM(entry.Certificates))
+
+	p7b, err := EncodeCertificatesPKCS7(entry.Certificates)
+	if err != nil {
+		return fmt.Errorf("error encoding certificate chain as PKCS#7\nError: %s", err)
+	}
+	r.CertificateChainP7b = types.StringValue(base64.StdEncoding.EncodeToString(p7b))
+	return nil
+}
+
 func (r *KeystoreResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_keystore"
 }
@@ -62,11 +353,16 @@ func (r *KeystoreResource) Metadata(ctx context.Context, req resource.MetadataRe
 func (r *KeystoreResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 
 	resp.Schema = schema.Schema{
+		Version: 1,
+
 		// This description is used by the documentation generator and the language server.
 		Description: `
         Keystore resource which creates a base64 encoded PKCS12 keystore file valid for 25 years using the keytool utility.
         The machine running Terraform needs to have the keytool utility installed. https://docs.oracle.com/javase/8/docs/technotes/tools/unix/keytool.html.
-        The file is persisted solely within the Terraform state as base64 text.
+        The file is persisted within the Terraform state as base64 text, and can additionally be written to a local file with destination_path.
+        A tls_self_signed_cert of the hashicorp/tls provider can be moved into a jks_keystore with a moved block, which keeps its key and certificate.
+        With trusted_certificates the keystore holds trusted certificates instead of a key pair, and is encoded by the provider itself without keytool.
+        Such a keystore can be written without a password, with neither an integrity MAC nor encrypted certificates, which Java 18 and later load without a password.
         `,
 
 		Attributes: map[string]schema.Attribute{
@@ -78,91 +374,495 @@ func (r *KeystoreResource) Schema(ctx context.Context, req resource.SchemaReques
 				},
 			},
 			"password": schema.StringAttribute{
-				Description: "Password for the keystore and the single key in the keystore",
-				Required:    true,
-				Sensitive:   true,
+				Description: "Password for the keystore and the single key in the keystore, at least 6 characters long. Exactly one of password and password_wo must be set, " +
+					"or at most one with trusted_certificates, whose keystore is written without a password when neither is set",
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(MinPasswordLength),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIf(
+						func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+							// The previous write-only password is not known, so the keystore cannot be re-encrypted.
+							// A keystore of trusted certificates is encoded again instead.
+							var trusted types.Map
+							resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("trusted_certificates"), &trusted)...)
+							resp.RequiresReplace = req.StateValue.IsNull() && !req.PlanValue.IsNull() && trusted.IsNull()
+						},
+						"Switching from password_wo to password requires replacement.",
+						"Switching from password_wo to password requires replacement.",
+					),
+				},
+			},
+			"password_wo": schema.StringAttribute{
+				Description: "Write-only password for the keystore and the single key in the keystore, at least 6 characters long, which is never stored in the Terraform state. " +
+					"Requires Terraform 1.11 or later. Changes are only applied when password_wo_version changes",
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(MinPasswordLength),
+				},
+			},
+			"password_wo_version": schema.Int64Attribute{
+				Description: "Version of password_wo. Changing it rotates the password by re-encrypting the keystore in place with password_wo, which keeps its key pair. " +
+					"The keystore is opened with previous_password_wo, or with password when switching from password to password_wo",
+				Optional: true,
+				PlanModifiers: []planmodifier.Int64{
+					requirePreviousPassword{},
+				},
+			},
+			"previous_password_wo": schema.StringAttribute{
+				Description: "Write-only password the keystore is encrypted with before password_wo_version changes, which is never stored in the Terraform state. " +
+					"Required to rotate a password_wo, as Terraform does not keep the previous write-only value. It is only used when password_wo_version changes",
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(MinPasswordLength),
+				},
 			},
 			"file": schema.StringAttribute{
 
-				Description: "Base64 encoded keystore file",
+				Description: "Base64 encoded keystore file. Null when store_in_state is false",
 				Computed:    true,
 				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{nullUnlessStored: true},
+				},
 			},
-			"common_name": schema.StringAttribute{
-				Description: "Common Name (CN)",
+			"subject": schema.SingleNestedAttribute{
+				Description: "Distinguished name of the self-signed certificate. At least one field must be set when it is configured. Changing it creates a new keystore",
 				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"common_name": schema.StringAttribute{
+						Description: "Common Name (CN)",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"organization": schema.StringAttribute{
+						Description: "Organization (O)",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"organizational_unit": schema.StringAttribute{
+						Description: "Organizational Unit (OU)",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"locality": schema.StringAttribute{
+						Description: "Locality (L)",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"state": schema.StringAttribute{
+						Description: "State (S)",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"country": schema.StringAttribute{
+						Description: "Country (C), as a two-letter ISO 3166 code",
+						Optional:    true,
+						Validators: []validator.String{
+							stringvalidator.RegexMatches(regexp.MustCompile(`^[A-Za-z]{2}$`), "must be a two-letter country code"),
+						},
+					},
+				},
+				Validators: []validator.Object{
+					nonEmptySubject{},
+				},
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"key_algorithm": schema.StringAttribute{
+				Description: "Algorithm of the generated key pair: RSA, EC or Ed25519. Defaults to RSA. Changing it creates a new keystore. " +
+					"It is null on a keystore moved from tls_self_signed_cert until it is configured, which does not create a new keystore, and with trusted_certificates",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(KeyAlgorithmRSA),
+				Validators: []validator.String{
+					stringvalidator.OneOf(KeyAlgorithmRSA, KeyAlgorithmEC, KeyAlgorithmEd25519),
+				},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+					nullKeyAlgorithmUnlessGenerated{},
+					stringplanmodifier.RequiresReplaceIf(
+						func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+							resp.RequiresReplace = !req.StateValue.IsNull()
+						},
+						"Changing the algorithm of a generated key pair requires replacement.",
+						"Changing the algorithm of a generated key pair requires replacement.",
+					),
 				},
 			},
-			"organization": schema.StringAttribute{
-				Description: "Organization (O)",
-				Optional:    true,
+			"signature_algorithm": schema.StringAttribute{
+				Description: "Algorithm signing the self-signed certificate: SHA256withRSA, SHA384withRSA, SHA512withRSA or RSASSA-PSS for RSA keys, " +
+					"SHA256withECDSA or SHA384withECDSA for EC keys, Ed25519 for Ed25519 keys. Defaults to the keytool default for the key. Changing it creates a new keystore",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(
+						SignatureAlgorithmSHA256WithRSA, SignatureAlgorithmSHA384WithRSA, SignatureAlgorithmSHA512WithRSA, SignatureAlgorithmRSASSAPSS,
+						SignatureAlgorithmSHA256WithECDSA, SignatureAlgorithmSHA384WithECDSA, SignatureAlgorithmEd25519,
+					),
+				},
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+					stringplanmodifier.RequiresReplace(),
 				},
 			},
-			"organizational_unit": schema.StringAttribute{
-				Description: "Organizational Unit (OU)",
+			"pkcs12_encryption": schema.StringAttribute{
+				Description: "Algorithms protecting the keystore. modern uses AES-256 with PBKDF2 and an HmacPBESHA256 MAC, the keytool defaults since Java 17. " +
+					"legacy uses PBEWithSHA1AndDESede for the key, PBEWithSHA1AndRC2_40 for the certificates and an HmacPBESHA1 MAC, which Java 8 before 8u301, OpenSSL 1.0 and older .NET versions can read. " +
+					"custom only sets the MAC, with mac_algorithm and mac_iterations: the key and the certificates are encrypted as modern does. " +
+					"When unset, the keystore is protected with the defaults of the installed keytool, which depend on its Java version. " +
+					"When set, it requires keytool of Java 8u301, 11.0.12, 17 or later, as older versions ignore it, which is reported as an error. Changing it re-encrypts the keystore in place",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(PKCS12EncryptionModern, PKCS12EncryptionLegacy, PKCS12EncryptionCustom),
+				},
+			},
+			"mac_algorithm": schema.StringAttribute{
+				Description: "Integrity MAC algorithm of a custom pkcs12_encryption: HmacPBESHA1, HmacPBESHA256, HmacPBESHA384 or HmacPBESHA512. Required when pkcs12_encryption is custom",
 				Optional:    true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+				Validators: []validator.String{
+					stringvalidator.OneOf(MacAlgorithmSHA1, MacAlgorithmSHA256, MacAlgorithmSHA384, MacAlgorithmSHA512),
 				},
 			},
-			"locality": schema.StringAttribute{
-				Description: "Locality (L)",
+			"mac_iterations": schema.Int64Attribute{
+				Description: "Iteration count of the integrity MAC of a custom pkcs12_encryption. Defaults to 10000",
 				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, int64(maxIterations)),
+				},
+			},
+			"signed_certificate_pem": schema.StringAttribute{
+				Description: "CA-signed certificate for the key in the keystore, in PEM format. " +
+					"When set, it is installed as a certificate reply under the existing alias, replacing the self-signed certificate while keeping the private key. " +
+					"The certificate must carry the public key of the keystore's private key, so it only applies to an existing keystore: " +
+					"set it in a later apply, once certificate_request_pem has been signed. Setting it when the keystore is created is an error. " +
+					"Removing it leaves the installed chain in place.",
+				Optional: true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+					rejectOnCreate{},
 				},
 			},
-			"state": schema.StringAttribute{
-				Description: "State (S)",
+			"ca_chain_pem": schema.StringAttribute{
+				Description: "PEM encoded certificates of the issuing CA chain, ordered from the issuer of signed_certificate_pem up to the root. Installed together with signed_certificate_pem.",
 				Optional:    true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+			},
+			"ca_chain_p7b": schema.StringAttribute{
+				Description: "Issuing CA chain as a PKCS#7 bundle, such as a .p7b file, in DER or BER as enterprise CAs write it, base64 encoded or in PEM format. Installed together with signed_certificate_pem. Conflicts with ca_chain_pem.",
+				Optional:    true,
+			},
+			"trusted_certificates": schema.MapAttribute{
+				Description: "PEM encoded certificates to trust, by alias, each holding exactly one certificate. " +
+					"When set, the keystore holds a trusted certificate entry for each of them instead of a generated key pair, and the certificate attributes are null. " +
+					"Conflicts with subject, key_algorithm, signature_algorithm, signed_certificate_pem, ca_chain_pem and ca_chain_p7b. " +
+					"Changing the certificates re-encodes the keystore in place, setting or removing it creates a new keystore",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.SizeAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplaceIf(
+						func(ctx context.Context, req planmodifier.MapRequest, resp *mapplanmodifier.RequiresReplaceIfFuncResponse) {
+							resp.RequiresReplace = req.StateValue.IsNull() != req.PlanValue.IsNull()
+						},
+						"Switching between a key pair and trusted certificates requires replacement.",
+						"Switching between a key pair and trusted certificates requires replacement.",
+					),
 				},
 			},
-			"country": schema.StringAttribute{
-				Description: "Country (C)",
+			"destination_path": schema.StringAttribute{
+				Description: "Path of a local file the keystore is written to, in addition to the state. " +
+					"The file is replaced atomically, rewritten when it is changed or removed outside of Terraform, and deleted with the resource.",
+				Optional: true,
+			},
+			"file_permission": schema.StringAttribute{
+				Description: "Octal permission of the file at destination_path. Defaults to 0600",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("0600"),
+			},
+			"file_owner": schema.StringAttribute{
+				Description: "User name or id that owns the file at destination_path. Defaults to the user running Terraform",
 				Optional:    true,
+			},
+			"file_group": schema.StringAttribute{
+				Description: "Group name or id of the file at destination_path. Defaults to the group of the user running Terraform",
+				Optional:    true,
+			},
+			"destination_sha256": schema.StringAttribute{
+				Description: "SHA-256 digest of the keystore file at destination_path",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					destinationDigestFromFile{},
+				},
+			},
+			"store_in_state": schema.BoolAttribute{
+				Description: "Whether the keystore is kept in the file attribute of the Terraform state. Defaults to true. " +
+					"When false, the keystore is only written to destination_path, which is then required, and the state keeps its digest and certificate metadata. " +
+					"A local file that goes missing or no longer matches the digest causes the keystore to be recreated",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"certificate_subject": schema.StringAttribute{
+				Description: "Subject of the certificate of the key entry",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{},
+				},
+			},
+			"certificate_fingerprint_sha256": schema.StringAttribute{
+				Description: "Hex encoded SHA-256 fingerprint of the certificate of the key entry",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{},
+				},
+			},
+			"certificate_not_after": schema.StringAttribute{
+				Description: "Expiry of the certificate of the key entry, in RFC 3339 format",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{},
+				},
+			},
+			"certificate_pem": schema.StringAttribute{
+				Description: "Certificate of the key entry, in PEM format",
+				Computed:    true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+					keepUnlessContentChanges{},
+				},
+			},
+			"certificate_chain_pem": schema.StringAttribute{
+				Description: "Certificate chain of the key entry in PEM format, leaf first",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{},
+				},
+			},
+			"certificate_chain_p7b": schema.StringAttribute{
+				Description: "Certificate chain of the key entry as a base64 encoded PKCS#7 bundle, a degenerate SignedData as in .p7b files, leaf first",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{},
+				},
+			},
+			"certificate_request_pem": schema.StringAttribute{
+				Description: "PEM encoded PKCS#10 certificate request for the key entry, generated with keytool -certreq. " +
+					"Have it signed by a CA and install the result with signed_certificate_pem. " +
+					"Null on a keystore moved from tls_self_signed_cert or upgraded from an earlier version until its content changes",
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					keepUnlessContentChanges{},
 				},
 			},
 		},
 	}
 }
 
-func (r *KeystoreResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	// Prevent panic if the provider has not been configured.
-	if req.ProviderData == nil {
+func (r *KeystoreResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	validators := []resource.ConfigValidator{
+		resourcevalidator.Conflicting(path.MatchRoot("ca_chain_pem"), path.MatchRoot("ca_chain_p7b")),
+	}
+	// A keystore of trusted certificates has no key pair.
+	for _, name := range []string{"subject", "key_algorithm", "signature_algorithm", "signed_certificate_pem", "ca_chain_pem", "ca_chain_p7b"} {
+		validators = append(validators, resourcevalidator.Conflicting(path.MatchRoot("trusted_certificates"), path.MatchRoot(name)))
+	}
+	return validators
+}
+
+func (r *KeystoreResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data KeystoreResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	passwordKnown := !data.Password.IsUnknown() && !data.PasswordWo.IsUnknown()
+	switch {
+	case !data.HasKeyPair():
+		if passwordKnown && !data.Password.IsNull() && !data.PasswordWo.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("password"),
+				"Invalid password configuration",
+				"At most one of password and password_wo can be set with trusted_certificates.",
+			)
+		}
+		if passwordKnown && data.Password.IsNull() && data.PasswordWo.IsNull() && !data.Pkcs12Encryption.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("pkcs12_encryption"),
+				"Invalid PKCS12 encryption configuration",
+				"pkcs12_encryption requires a password: a keystore of trusted certificates without a password has neither an integrity MAC nor encrypted certificates.",
+			)
+		}
+	case passwordKnown && data.Password.IsNull() == data.PasswordWo.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password"),
+			"Invalid password configuration",
+			"Exactly one of password and password_wo must be set.",
+		)
+	}
+
+	if !data.PasswordWoVersion.IsNull() && data.PasswordWo.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password_wo_version"),
+			"Invalid password configuration",
+			"password_wo_version can only be set together with password_wo.",
+		)
+	}
+
+	if !data.PreviousPasswordWo.IsNull() && data.PasswordWoVersion.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("previous_password_wo"),
+			"Invalid password configuration",
+			"previous_password_wo can only be set together with password_wo_version.",
+		)
+	}
+
+	if !data.StoreInState.IsUnknown() && !data.StoreInState.IsNull() && !data.StoreInState.ValueBool() && data.DestinationPath.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("destination_path"),
+			"Invalid store_in_state configuration",
+			"destination_path is required when store_in_state is false.",
+		)
+	}
+
+	if !data.CaChainP7b.IsUnknown() && !data.CaChainP7b.IsNull() {
+		if _, err := ParseCertificatesPKCS7(data.CaChainP7b.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ca_chain_p7b"),
+				"Invalid PKCS#7 certificate chain",
+				fmt.Sprintf("The CA chain could not be parsed.\nError: %s", err),
+			)
+		}
+	}
+
+	// An unset pkcs12_encryption leaves the protection to keytool.
+	if !data.Pkcs12Encryption.IsUnknown() {
+		custom := data.Pkcs12Encryption.ValueString() == PKCS12EncryptionCustom
+		switch {
+		case custom && data.MacAlgorithm.IsNull():
+			resp.Diagnostics.AddAttributeError(
+				path.Root("mac_algorithm"),
+				"Invalid PKCS12 encryption configuration",
+				"mac_algorithm is required when pkcs12_encryption is custom.",
+			)
+		case !custom && !data.MacAlgorithm.IsNull():
+			resp.Diagnostics.AddAttributeError(
+				path.Root("mac_algorithm"),
+				"Invalid PKCS12 encryption configuration",
+				"mac_algorithm only applies when pkcs12_encryption is custom.",
+			)
+		}
+		if !custom && !data.MacIterations.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("mac_iterations"),
+				"Invalid PKCS12 encryption configuration",
+				"mac_iterations only applies when pkcs12_encryption is custom.",
+			)
+		}
+	}
+
+	// An unset key_algorithm is RSA, its default.
+	if data.KeyAlgorithm.IsUnknown() {
 		return
 	}
+	algorithm := data.KeyAlgorithm.ValueString()
+	if data.KeyAlgorithm.IsNull() {
+		algorithm = KeyAlgorithmRSA
+	}
+
+	allowed, ok := signatureAlgorithms[algorithm]
+	signature := data.SignatureAlgorithm.ValueString()
+	if ok && !data.SignatureAlgorithm.IsNull() && !data.SignatureAlgorithm.IsUnknown() && !slices.Contains(allowed, signature) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("signature_algorithm"),
+			"Invalid key pair configuration",
+			fmt.Sprintf("signature_algorithm %s does not apply to %s keys, which sign with %s.", signature, algorithm, strings.Join(allowed, ", ")),
+		)
+	}
+}
+
+func (r *KeystoreResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	runner, diags := keytoolRunnerFromProviderData(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.runner = runner
 }
 
 func (r *KeystoreResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data KeystoreResourceModel
 
-	// Read Terraform plan data into the model
+	// Read Terraform plan data into the model. Write-only attributes are
+	// only present in the configuration.
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("password_wo"), &data.PasswordWo)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	model := data.ToKeystoreModel()
-
-	b64File, err := model.CreateKeystoreBase64()
-	model.File = b64File
-
-	if err != nil {
-		resp.Diagnostics.AddError("Error during create operation", err.Error())
+	// A certificate reply cannot match a key pair that is yet to be
+	// generated. Known values are rejected at plan time.
+	if !data.SignedCertificate.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("signed_certificate_pem"),
+			"Certificate reply on create",
+			signedCertificateOnCreateDetail,
+		)
 		return
 	}
 
+	var b64File string
+	if data.HasKeyPair() {
+		model := data.ToKeystoreModel()
+		model.Runner = r.runner
+
+		var err error
+		b64File, err = model.CreateKeystoreBase64(ctx)
+		model.File = b64File
+
+		if err != nil {
+			addKeystoreError(&resp.Diagnostics, "create", err, data.errorPaths())
+			return
+		}
+
+		if err := data.SetCertificateRequest(ctx, model); err != nil {
+			addKeystoreError(&resp.Diagnostics, "create", err, data.errorPaths())
+			return
+		}
+	} else {
+		var diags diag.Diagnostics
+		b64File, diags = data.EncodeTrustedCertificates(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	data.Id = types.StringValue(uuid.New().String())
-	data.File = types.StringValue(model.File)
+
+	if err := data.SetKeystore(ctx, b64File, nil); err != nil {
+		addKeystoreError(&resp.Diagnostics, "create", err, data.errorPaths())
+		return
+	}
 
 	// Write logs using the tflog package
 	// Documentation: https://terraform.io/plugin/log
@@ -182,6 +882,25 @@ func (r *KeystoreResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	// Record the digest of the file on disk. A file that was changed or
+	// removed no longer matches the planned digest and is rewritten from
+	// state. Without a copy in state, the keystore has to be recreated.
+	if dest, ok := data.Destination(); ok {
+		digest, err := dest.SHA256()
+		if err != nil {
+			resp.Diagnostics.AddError("Error during read operation", err.Error())
+			return
+		}
+		if !data.StoredInState() && digest != data.DestinationSha256.ValueString() {
+			tflog.Warn(ctx, "keystore file is missing or was modified outside of Terraform, planning to recreate it", map[string]interface{}{
+				"destination_path": dest.Path,
+			})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		data.DestinationSha256 = types.StringValue(digest)
+	}
+
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -190,29 +909,83 @@ func (r *KeystoreResource) Update(ctx context.Context, req resource.UpdateReques
 	var data KeystoreResourceModel
 	var oldData KeystoreResourceModel
 
-	// Read Terraform plan data into the model
+	// Read Terraform plan data into the model. Write-only attributes are
+	// only present in the configuration.
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("password_wo"), &data.PasswordWo)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("previous_password_wo"), &data.PreviousPasswordWo)...)
 	resp.Diagnostics.Append(req.State.Get(ctx, &oldData)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if reflect.DeepEqual(data, oldData) {
-		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
-		return
+	// While rotating a write-only password, the keystore is opened with
+	// previous_password_wo.
+	errorPaths := data.errorPaths()
+	if oldData.Password.IsNull() && !data.PasswordWoVersion.Equal(oldData.PasswordWoVersion) {
+		errorPaths[ErrIncorrectPassword] = path.Root("previous_password_wo")
 	}
 
-	newModel := data.ToKeystoreModel()
-	oldModel := oldData.ToKeystoreModel()
-	b64File, err := oldModel.UpdateKeystoreBase64(newModel.Password)
+	b64File, err := oldData.LoadKeystore()
 	if err != nil {
-		resp.Diagnostics.AddError("Error during update operation", err.Error())
+		addKeystoreError(&resp.Diagnostics, "update", err, errorPaths)
 		return
 	}
 
-	newModel.File = b64File
-	data.File = types.StringValue(newModel.File)
+	// Changes to anything but the content attributes only update the local
+	// copy. A keystore of trusted certificates is encoded again from the
+	// configuration.
+	switch {
+	case !KeystoreContentChanged(data, oldData):
+		data.CertificateRequestPem = oldData.CertificateRequestPem
+	case !data.HasKeyPair():
+		var diags diag.Diagnostics
+		b64File, diags = data.EncodeTrustedCertificates(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	default:
+		newModel := data.ToKeystoreModel()
+		oldModel := oldData.ToKeystoreModel()
+		oldModel.Runner = r.runner
+		oldModel.File = b64File
+		// The keystore is written with the planned encryption.
+		oldModel.Protection = newModel.Protection
+		oldModel.Password = data.PriorKeystorePassword(oldData)
+
+		replyChanged := !data.SignedCertificate.Equal(oldData.SignedCertificate) || !data.CaChain.Equal(oldData.CaChain) || !data.CaChainP7b.Equal(oldData.CaChainP7b)
+		if replyChanged && !data.SignedCertificate.IsNull() {
+			caChain, err := data.CaChainPEM()
+			if err == nil {
+				b64File, err = oldModel.InstallCertificateReply(ctx, data.SignedCertificate.ValueString(), caChain)
+			}
+			if err != nil {
+				addKeystoreError(&resp.Diagnostics, "update", err, errorPaths)
+				return
+			}
+			oldModel.File = b64File
+		}
+
+		b64File, err = oldModel.UpdateKeystoreBase64(ctx, newModel.Password)
+		if err != nil {
+			addKeystoreError(&resp.Diagnostics, "update", err, errorPaths)
+			return
+		}
+
+		newModel.Runner = r.runner
+		newModel.File = b64File
+		if err := data.SetCertificateRequest(ctx, newModel); err != nil {
+			addKeystoreError(&resp.Diagnostics, "update", err, errorPaths)
+			return
+		}
+	}
+
+	if err := data.SetKeystore(ctx, b64File, &oldData); err != nil {
+		addKeystoreError(&resp.Diagnostics, "update", err, errorPaths)
+		return
+	}
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -227,4 +1000,11 @@ func (r *KeystoreResource) Delete(ctx context.Context, req resource.DeleteReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	if dest, ok := data.Destination(); ok {
+		if err := dest.Remove(); err != nil {
+			resp.Diagnostics.AddError("Error during delete operation", err.Error())
+			return
+		}
+	}
 }
@@ -0,0 +1,667 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Unit tests of the jks_keystore CRUD methods, with keytool replaced by
+// fakeKeytool. The acceptance tests in keystore_resource_test.go cover
+// the same paths against Terraform and a JDK.
+
+// testKeystoreResource returns a jks_keystore configured with runner as
+// provider data.
+func testKeystoreResource(t *testing.T, runner KeytoolRunner) *KeystoreResource {
+	t.Helper()
+
+	r := &KeystoreResource{}
+	var resp resource.ConfigureResponse
+	r.Configure(context.Background(), resource.ConfigureRequest{ProviderData: runner}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	return r
+}
+
+// testKeystoreResourcePlan returns the planned values of a jks_keystore with
+// a password and a common name, stored in state.
+func testKeystoreResourcePlan(password string) KeystoreResourceModel {
+	return KeystoreResourceModel{
+		Id:       types.StringUnknown(),
+		Password: types.StringValue(password),
+		Subject: types.ObjectValueMust(subjectAttrTypes, map[string]attr.Value{
+			"common_name":         types.StringValue("service.example.com"),
+			"organization":        types.StringNull(),
+			"organizational_unit": types.StringNull(),
+			"locality":            types.StringNull(),
+			"state":               types.StringNull(),
+			"country":             types.StringNull(),
+		}),
+		KeyAlgorithm:        types.StringValue(KeyAlgorithmEC),
+		Pkcs12Encryption:    types.StringValue(PKCS12EncryptionModern),
+		TrustedCertificates: types.MapNull(types.StringType),
+		FilePermission:      types.StringValue("0600"),
+		StoreInState:        types.BoolValue(true),
+	}
+}
+
+// testKeystoreResourceValue converts a model to a value of the jks_keystore
+// schema.
+func testKeystoreResourceValue(t *testing.T, data KeystoreResourceModel) tfsdk.State {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	(&KeystoreResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := state.Set(ctx, &data); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	return state
+}
+
+// testEmptyKeystoreResourceState returns a null jks_keystore state for the
+// response of a CRUD method.
+func testEmptyKeystoreResourceState(t *testing.T) tfsdk.State {
+	t.Helper()
+
+	state := testKeystoreResourceValue(t, testKeystoreResourcePlan(""))
+	state.Raw = tftypes.NewValue(state.Raw.Type(), nil)
+	return state
+}
+
+// testCreateKeystoreResource runs Create for the planned values.
+func testCreateKeystoreResource(t *testing.T, r *KeystoreResource, planned KeystoreResourceModel) (KeystoreResourceModel, diag.Diagnostics) {
+	t.Helper()
+	ctx := context.Background()
+
+	value := testKeystoreResourceValue(t, planned)
+	resp := resource.CreateResponse{State: testEmptyKeystoreResourceState(t)}
+	r.Create(ctx, resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: value.Schema, Raw: value.Raw},
+		Config: tfsdk.Config{Schema: value.Schema, Raw: value.Raw},
+	}, &resp)
+
+	var created KeystoreResourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &created)...)
+	}
+	return created, resp.Diagnostics
+}
+
+// testUpdateKeystoreResource runs Update from the prior to the planned values.
+func testUpdateKeystoreResource(t *testing.T, r *KeystoreResource, prior, planned KeystoreResourceModel) (KeystoreResourceModel, diag.Diagnostics) {
+	t.Helper()
+	ctx := context.Background()
+
+	value := testKeystoreResourceValue(t, planned)
+	resp := resource.UpdateResponse{State: testEmptyKeystoreResourceState(t)}
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:   tfsdk.Plan{Schema: value.Schema, Raw: value.Raw},
+		Config: tfsdk.Config{Schema: value.Schema, Raw: value.Raw},
+		State:  testKeystoreResourceValue(t, prior),
+	}, &resp)
+
+	var updated KeystoreResourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &updated)...)
+	}
+	return updated, resp.Diagnostics
+}
+
+// testCheckAttributeError checks that diags is a single error with summary,
+// reported on the attribute at p, or on no attribute if p is nil.
+func testCheckAttributeError(t *testing.T, diags diag.Diagnostics, summary string, p *path.Path) {
+	t.Helper()
+
+	if len(diags) != 1 || diags[0].Severity() != diag.SeverityError || diags[0].Summary() != summary {
+		t.Fatalf("expected an error %q, got %v", summary, diags)
+	}
+	withPath, ok := diags[0].(diag.DiagnosticWithPath)
+	switch {
+	case p == nil && ok:
+		t.Errorf("expected no attribute path, got %s", withPath.Path())
+	case p != nil && (!ok || !withPath.Path().Equal(*p)):
+		t.Errorf("expected the error on %s, got %v", p, diags[0])
+	}
+}
+
+func TestKeystoreResourceCreate(t *testing.T) {
+	fake := newFakeKeytool(t)
+
+	created, diags := testCreateKeystoreResource(t, testKeystoreResource(t, fake), testKeystoreResourcePlan("MyPassword12345"))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if commands := fake.commands(); !slices.Equal(commands, []string{"-genkeypair", "-certreq"}) {
+		t.Errorf("expected keytool -genkeypair and -certreq, got %v", commands)
+	}
+	if keyalg := fakeKeytoolArg(fake.runs[0], "-keyalg"); keyalg != KeyAlgorithmEC {
+		t.Errorf("expected -keyalg EC, got %s", keyalg)
+	}
+	if created.Id.IsUnknown() || created.Id.IsNull() {
+		t.Error("expected an id")
+	}
+	if subject := created.CertificateSubject.ValueString(); subject != "CN=service.example.com" {
+		t.Errorf("unexpected certificate subject %s", subject)
+	}
+	testDecodeBase64Keystore(t, created.File.ValueString(), "MyPassword12345")
+
+	// The certificate request is for the generated key, and is what
+	// InstallCertificateReply expects to have been signed.
+	block, _ := pem.Decode([]byte(created.CertificateRequestPem.ValueString()))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		t.Fatalf("expected a PEM encoded CERTIFICATE REQUEST, got %q", created.CertificateRequestPem.ValueString())
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := ParseCertificatesPEM(created.CertificatePem.ValueString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.CheckSignature() != nil || !PublicKeysEqual(request.PublicKey, certs[0].PublicKey) {
+		t.Error("expected a certificate request signed by the key of the keystore")
+	}
+}
+
+func TestKeystoreResourceCreateSignedCertificate(t *testing.T) {
+	fake := newFakeKeytool(t)
+
+	// No certificate can match the key pair that is yet to be generated.
+	planned := testKeystoreResourcePlan("MyPassword12345")
+	planned.SignedCertificate = types.StringValue(testSelfSignedCertificatePEM(t, "service.example.com"))
+	_, diags := testCreateKeystoreResource(t, testKeystoreResource(t, fake), planned)
+	signedCertificate := path.Root("signed_certificate_pem")
+	testCheckAttributeError(t, diags, "Certificate reply on create", &signedCertificate)
+	if len(fake.runs) != 0 {
+		t.Errorf("expected no key pair to be generated, got %v", fake.commands())
+	}
+}
+
+func TestKeystoreResourceCreateErrors(t *testing.T) {
+	keyAlgorithm := path.Root("key_algorithm")
+
+	tests := []struct {
+		name    string
+		setup   func(*fakeKeytool)
+		summary string
+		path    *path.Path
+	}{
+		{"keytool missing", func(f *fakeKeytool) { f.missing() }, "keytool not found", nil},
+		{"unsupported algorithm", func(f *fakeKeytool) {
+			f.fail("-genkeypair", "keytool error: java.security.NoSuchAlgorithmException: EC KeyPairGenerator not available")
+		}, "Unsupported algorithm", &keyAlgorithm},
+		{"unrecognized", func(f *fakeKeytool) {
+			f.fail("-genkeypair", "keytool error: java.lang.Exception: something else")
+		}, "Error during create operation", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeKeytool(t)
+			tt.setup(fake)
+
+			_, diags := testCreateKeystoreResource(t, testKeystoreResource(t, fake), testKeystoreResourcePlan("MyPassword12345"))
+			testCheckAttributeError(t, diags, tt.summary, tt.path)
+		})
+	}
+}
+
+func TestKeystoreResourceValidateStoreInState(t *testing.T) {
+	validate := func(data KeystoreResourceModel) diag.Diagnostics {
+		value := testKeystoreResourceValue(t, data)
+		var resp resource.ValidateConfigResponse
+		(&KeystoreResource{}).ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+			Config: tfsdk.Config{Schema: value.Schema, Raw: value.Raw},
+		}, &resp)
+		return resp.Diagnostics
+	}
+
+	// The missing destination_path is reported at plan time, before keytool
+	// generates a key pair.
+	config := testKeystoreResourcePlan("MyPassword12345")
+	config.StoreInState = types.BoolValue(false)
+	destinationPath := path.Root("destination_path")
+	testCheckAttributeError(t, validate(config), "Invalid store_in_state configuration", &destinationPath)
+
+	config.DestinationPath = types.StringUnknown()
+	if diags := validate(config); diags.HasError() {
+		t.Errorf("expected an unknown destination_path to be accepted, got %v", diags)
+	}
+	config.DestinationPath = types.StringValue(filepath.Join(t.TempDir(), "keystore.p12"))
+	if diags := validate(config); diags.HasError() {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestKeystoreResourceUpdate(t *testing.T) {
+	fake := newFakeKeytool(t)
+	r := testKeystoreResource(t, fake)
+
+	created, diags := testCreateKeystoreResource(t, r, testKeystoreResourcePlan("MyPassword12345"))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	planned := created
+	planned.Password = types.StringValue("AnotherPassword")
+	updated, diags := testUpdateKeystoreResource(t, r, created, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if commands := fake.commands(); !slices.Equal(commands, []string{"-genkeypair", "-certreq", "-importkeystore", "-certreq"}) {
+		t.Errorf("expected the keystore to be re-encrypted, got %v", commands)
+	}
+	testDecodeBase64Keystore(t, updated.File.ValueString(), "AnotherPassword")
+	if updated.CertificateFingerprintSha256 != created.CertificateFingerprintSha256 {
+		t.Error("expected the certificate to be kept")
+	}
+}
+
+func TestKeystoreResourceUpdateEncryption(t *testing.T) {
+	fake := newFakeKeytool(t)
+	r := testKeystoreResource(t, fake)
+
+	created, diags := testCreateKeystoreResource(t, r, testKeystoreResourcePlan("MyPassword12345"))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	planned := created
+	planned.Pkcs12Encryption = types.StringValue(PKCS12EncryptionCustom)
+	planned.MacAlgorithm = types.StringValue(MacAlgorithmSHA1)
+	updated, diags := testUpdateKeystoreResource(t, r, created, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if commands := fake.commands(); !slices.Equal(commands, []string{"-genkeypair", "-certreq", "-importkeystore", "-certreq"}) {
+		t.Errorf("expected the keystore to be re-encrypted, got %v", commands)
+	}
+	data, err := base64.StdEncoding.DecodeString(updated.File.ValueString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewPKCS12Protection(PKCS12EncryptionCustom, MacAlgorithmSHA1, 0)
+	if protection, err := inspectPKCS12Protection(data); err != nil || protection != expected {
+		t.Errorf("expected %+v, got %+v, %v", expected, protection, err)
+	}
+	if updated.CertificateFingerprintSha256 != created.CertificateFingerprintSha256 {
+		t.Error("expected the certificate to be kept")
+	}
+}
+
+func TestKeystoreResourceUpdateIncorrectPassword(t *testing.T) {
+	fake := newFakeKeytool(t)
+	r := testKeystoreResource(t, fake)
+
+	created, diags := testCreateKeystoreResource(t, r, testKeystoreResourcePlan("MyPassword12345"))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	// The prior state does not hold the password of the keystore.
+	prior := created
+	prior.Password = types.StringValue("WrongPassword")
+	planned := created
+	planned.Password = types.StringValue("AnotherPassword")
+
+	_, diags = testUpdateKeystoreResource(t, r, prior, planned)
+	password := path.Root("password")
+	testCheckAttributeError(t, diags, "Incorrect keystore password", &password)
+}
+
+func TestKeystoreResourceUpdateWriteOnlyPassword(t *testing.T) {
+	// create returns a keystore encrypted with password_wo, as its state
+	// without the write-only value.
+	create := func(t *testing.T) (*fakeKeytool, *KeystoreResource, KeystoreResourceModel) {
+		fake := newFakeKeytool(t)
+		r := testKeystoreResource(t, fake)
+
+		planned := testKeystoreResourcePlan("")
+		planned.Password = types.StringNull()
+		planned.PasswordWo = types.StringValue("MyPassword12345")
+		planned.PasswordWoVersion = types.Int64Value(1)
+		created, diags := testCreateKeystoreResource(t, r, planned)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		created.PasswordWo = types.StringNull()
+		return fake, r, created
+	}
+
+	t.Run("rotate", func(t *testing.T) {
+		fake, r, prior := create(t)
+
+		planned := prior
+		planned.PasswordWo = types.StringValue("MyRotatedPassword12345")
+		planned.PasswordWoVersion = types.Int64Value(2)
+		planned.PreviousPasswordWo = types.StringValue("MyPassword12345")
+		updated, diags := testUpdateKeystoreResource(t, r, prior, planned)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+
+		if commands := fake.commands(); !slices.Equal(commands, []string{"-genkeypair", "-certreq", "-importkeystore", "-certreq"}) {
+			t.Errorf("expected the keystore to be re-encrypted, got %v", commands)
+		}
+		testDecodeBase64Keystore(t, updated.File.ValueString(), "MyRotatedPassword12345")
+		if updated.CertificateFingerprintSha256 != prior.CertificateFingerprintSha256 {
+			t.Error("expected the key pair to be kept")
+		}
+	})
+
+	t.Run("incorrect previous password", func(t *testing.T) {
+		_, r, prior := create(t)
+
+		planned := prior
+		planned.PasswordWo = types.StringValue("MyRotatedPassword12345")
+		planned.PasswordWoVersion = types.Int64Value(2)
+		planned.PreviousPasswordWo = types.StringValue("WrongPassword")
+		_, diags := testUpdateKeystoreResource(t, r, prior, planned)
+		previous := path.Root("previous_password_wo")
+		testCheckAttributeError(t, diags, "Incorrect keystore password", &previous)
+	})
+
+	// A password_wo changed without password_wo_version is not applied, and
+	// the keystore is not opened with it.
+	t.Run("unchanged version", func(t *testing.T) {
+		fake, r, prior := create(t)
+
+		planned := prior
+		planned.PasswordWo = types.StringValue("MyOtherPassword12345")
+		planned.DestinationPath = types.StringValue(filepath.Join(t.TempDir(), "keystore.p12"))
+		updated, diags := testUpdateKeystoreResource(t, r, prior, planned)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+
+		if commands := fake.commands(); !slices.Equal(commands, []string{"-genkeypair", "-certreq"}) {
+			t.Errorf("expected the keystore not to be re-encrypted, got %v", commands)
+		}
+		testDecodeBase64Keystore(t, updated.File.ValueString(), "MyPassword12345")
+		if updated.CertificateFingerprintSha256 != prior.CertificateFingerprintSha256 {
+			t.Error("expected the certificate attributes to be kept")
+		}
+	})
+}
+
+func TestKeystoreResourceReadAndDelete(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKeytool(t)
+	r := testKeystoreResource(t, fake)
+
+	destination := filepath.Join(t.TempDir(), "keystore.p12")
+	planned := testKeystoreResourcePlan("MyPassword12345")
+	planned.DestinationPath = types.StringValue(destination)
+	planned.StoreInState = types.BoolValue(false)
+
+	created, diags := testCreateKeystoreResource(t, r, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if !created.File.IsNull() {
+		t.Error("expected the keystore not to be stored in state")
+	}
+
+	read := func() resource.ReadResponse {
+		state := testKeystoreResourceValue(t, created)
+		resp := resource.ReadResponse{State: state}
+		r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+		}
+		return resp
+	}
+
+	if resp := read(); resp.State.Raw.IsNull() {
+		t.Fatal("expected an unchanged keystore file to be kept")
+	}
+
+	// A keystore file changed outside of Terraform is recreated.
+	if err := os.WriteFile(destination, []byte("changed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if resp := read(); !resp.State.Raw.IsNull() {
+		t.Error("expected a changed keystore file to be removed from state")
+	}
+
+	resp := resource.DeleteResponse{State: testKeystoreResourceValue(t, created)}
+	r.Delete(ctx, resource.DeleteRequest{State: testKeystoreResourceValue(t, created)}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", destination, err)
+	}
+}
+
+func TestKeystoreResourceUpdateCertificateReplyP7b(t *testing.T) {
+	fake := newFakeKeytool(t)
+	r := testKeystoreResource(t, fake)
+
+	created, diags := testCreateKeystoreResource(t, r, testKeystoreResourcePlan("MyPassword12345"))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	self, err := ParseCertificatesPEM(created.CertificatePem.ValueString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The CA issues a certificate for the key of the keystore.
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Issuing CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "service.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, ca, self[0].PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7b, err := EncodeCertificatesPKCS7([]*x509.Certificate{ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planned := created
+	planned.SignedCertificate = types.StringValue(EncodeCertificatesPEM([]*x509.Certificate{leaf}))
+	planned.CaChainP7b = types.StringValue(base64.StdEncoding.EncodeToString(p7b))
+	updated, diags := testUpdateKeystoreResource(t, r, created, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	chain := []*x509.Certificate{leaf, ca}
+	if updated.CertificateChainPem.ValueString() != EncodeCertificatesPEM(chain) {
+		t.Errorf("expected the installed chain, got %s", updated.CertificateChainPem)
+	}
+	installed, err := ParseCertificatesPKCS7(updated.CertificateChainP7b.ValueString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EncodeCertificatesPEM(installed) != EncodeCertificatesPEM(chain) {
+		t.Error("expected certificate_chain_p7b to hold the installed chain, leaf first")
+	}
+}
+
+// testTrustedKeystoreResourcePlan returns the planned values of a
+// jks_keystore holding the trusted certificates of certificates by alias,
+// without a password.
+func testTrustedKeystoreResourcePlan(certificates map[string]string) KeystoreResourceModel {
+	elements := make(map[string]attr.Value, len(certificates))
+	for alias, certificatePEM := range certificates {
+		elements[alias] = types.StringValue(certificatePEM)
+	}
+
+	data := testKeystoreResourcePlan("")
+	data.Password = types.StringNull()
+	data.Subject = types.ObjectNull(subjectAttrTypes)
+	data.KeyAlgorithm = types.StringNull()
+	data.Pkcs12Encryption = types.StringNull()
+	data.TrustedCertificates = types.MapValueMust(types.StringType, elements)
+	return data
+}
+
+// testCheckTrustedKeystore checks that the base64 encoded keystore holds
+// trusted certificates under aliases, protected with password, and with an
+// integrity MAC only when there is a password.
+func testCheckTrustedKeystore(t *testing.T, b64File, password string, aliases ...string) {
+	t.Helper()
+
+	ks := testDecodeBase64Keystore(t, b64File, password)
+	var found []string
+	for _, entry := range ks.Entries {
+		if entry.Type != EntryTypeTrustedCertificate {
+			t.Errorf("expected entry %q to be a trusted certificate, got %s", entry.Alias, entry.Type)
+		}
+		found = append(found, entry.Alias)
+	}
+	if !slices.Equal(found, aliases) {
+		t.Errorf("expected aliases %v, got %v", aliases, found)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(b64File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protection, err := inspectPKCS12Protection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasMac := protection.MacAlgorithm != ""; hasMac != (password != "") {
+		t.Errorf("expected an integrity MAC only with a password, got %q", protection.MacAlgorithm)
+	}
+}
+
+func TestKeystoreResourceTrustedCertificates(t *testing.T) {
+	fake := newFakeKeytool(t)
+	r := testKeystoreResource(t, fake)
+	rootPEM := testSelfSignedCertificatePEM(t, "Root CA")
+	issuingPEM := testSelfSignedCertificatePEM(t, "Issuing CA")
+
+	// Without a password, the keystore has no integrity MAC and is written
+	// without keytool.
+	planned := testTrustedKeystoreResourcePlan(map[string]string{"root": rootPEM})
+	created, diags := testCreateKeystoreResource(t, r, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	testCheckTrustedKeystore(t, created.File.ValueString(), "", "root")
+	if !created.CertificatePem.IsNull() || !created.CertificateRequestPem.IsNull() || !created.KeyAlgorithm.IsNull() {
+		t.Error("expected the key entry attributes of a keystore of trusted certificates to be null")
+	}
+
+	// Adding a certificate encodes the keystore again.
+	planned = testTrustedKeystoreResourcePlan(map[string]string{"root": rootPEM, "issuing": issuingPEM})
+	planned.Id = created.Id
+	updated, diags := testUpdateKeystoreResource(t, r, created, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	testCheckTrustedKeystore(t, updated.File.ValueString(), "", "issuing", "root")
+
+	// Setting a password, which does not require the previous one.
+	planned.Password = types.StringValue("MyPassword12345")
+	planned.Pkcs12Encryption = types.StringValue(PKCS12EncryptionLegacy)
+	updated, diags = testUpdateKeystoreResource(t, r, updated, planned)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	testCheckTrustedKeystore(t, updated.File.ValueString(), "MyPassword12345", "issuing", "root")
+
+	if commands := fake.commands(); len(commands) != 0 {
+		t.Errorf("expected keytool not to run, got %v", commands)
+	}
+
+	// Invalid certificates are reported on their alias.
+	planned = testTrustedKeystoreResourcePlan(map[string]string{"root": rootPEM + issuingPEM})
+	_, diags = testCreateKeystoreResource(t, r, planned)
+	rootPath := path.Root("trusted_certificates").AtMapKey("root")
+	testCheckAttributeError(t, diags, "Invalid certificate", &rootPath)
+}
+
+func TestKeystoreResourceValidateTrustedCertificates(t *testing.T) {
+	validate := func(data KeystoreResourceModel) diag.Diagnostics {
+		value := testKeystoreResourceValue(t, data)
+		var resp resource.ValidateConfigResponse
+		(&KeystoreResource{}).ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+			Config: tfsdk.Config{Schema: value.Schema, Raw: value.Raw},
+		}, &resp)
+		return resp.Diagnostics
+	}
+
+	config := testTrustedKeystoreResourcePlan(map[string]string{"root": testSelfSignedCertificatePEM(t, "Root CA")})
+	if diags := validate(config); diags.HasError() {
+		t.Errorf("expected a keystore of trusted certificates without a password to be accepted, got %v", diags)
+	}
+
+	// Without a password, there is nothing for pkcs12_encryption to apply to.
+	config.Pkcs12Encryption = types.StringValue(PKCS12EncryptionModern)
+	encryptionPath := path.Root("pkcs12_encryption")
+	testCheckAttributeError(t, validate(config), "Invalid PKCS12 encryption configuration", &encryptionPath)
+
+	config.Password = types.StringValue("MyPassword12345")
+	if diags := validate(config); diags.HasError() {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+
+	config.PasswordWo = types.StringValue("MyPassword12345")
+	passwordPath := path.Root("password")
+	testCheckAttributeError(t, validate(config), "Invalid password configuration", &passwordPath)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ resource.ResourceWithMoveState = &KeystoreResource{}
+
+// TLSSelfSignedCertModel is the part of the hashicorp/tls
+// tls_self_signed_cert state that is moved into a keystore.
+type TLSSelfSignedCertModel struct {
+	PrivateKeyPem types.String `tfsdk:"private_key_pem"`
+	CertPem       types.String `tfsdk:"cert_pem"`
+}
+
+func (r *KeystoreResource) MoveState(ctx context.Context) []resource.StateMover {
+	return []resource.StateMover{
+		{
+			SourceSchema: &schema.Schema{
+				Attributes: map[string]schema.Attribute{
+					"private_key_pem": schema.StringAttribute{Required: true, Sensitive: true},
+					"cert_pem":        schema.StringAttribute{Computed: true},
+				},
+			},
+			StateMover: moveTLSSelfSignedCert,
+		},
+		{
+			StateMover: moveTLSPrivateKey,
+		},
+	}
+}
+
+// isTLSResource reports whether the moved resource is typeName of the
+// hashicorp/tls provider.
+func isTLSResource(req resource.MoveStateRequest, typeName string) bool {
+	return req.SourceTypeName == typeName && strings.HasSuffix(req.SourceProviderAddress, "hashicorp/tls")
+}
+
+// moveTLSSelfSignedCert builds a keystore from the key and certificate of a
+// tls_self_signed_cert, so the certificate is kept rather than regenerated.
+func moveTLSSelfSignedCert(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if !isTLSResource(req, "tls_self_signed_cert") {
+		return
+	}
+	if req.SourceState == nil {
+		resp.Diagnostics.AddError("Unable to move resource state", "The tls_self_signed_cert state could not be read.")
+		return
+	}
+
+	var source TLSSelfSignedCertModel
+
+	resp.Diagnostics.Append(req.SourceState.Get(ctx, &source)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The configuration is not available when moving, and the source has no
+	// password, so the keystore is protected with a password generated for
+	// the move. The next apply re-encrypts it with the configured password,
+	// keeping the key and certificate.
+	password, err := movedKeystorePassword()
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to move resource state", err.Error())
+		return
+	}
+	model := KeystoreModel{Password: password}
+	b64File, err := model.ImportKeystoreBase64(ctx, source.PrivateKeyPem.ValueString(), source.CertPem.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to move resource state", err.Error())
+		return
+	}
+	certs, err := ParseCertificatesPEM(source.CertPem.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to move resource state", err.Error())
+		return
+	}
+
+	subject, diags := subjectFromName(ctx, certs[0].Subject)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := KeystoreResourceModel{
+		Id:                 types.StringValue(uuid.New().String()),
+		Password:           types.StringValue(password),
+		PasswordWo:         types.StringNull(),
+		PasswordWoVersion:  types.Int64Null(),
+		PreviousPasswordWo: types.StringNull(),
+		Subject:            subject,
+		// The key was not generated by the keystore: key_algorithm stays
+		// null until it is configured, instead of replacing the keystore
+		// when the key differs from the RSA default.
+		KeyAlgorithm:        types.StringNull(),
+		SignatureAlgorithm:  types.StringNull(),
+		Pkcs12Encryption:    types.StringNull(),
+		MacAlgorithm:        types.StringNull(),
+		MacIterations:       types.Int64Null(),
+		SignedCertificate:   types.StringNull(),
+		CaChain:             types.StringNull(),
+		CaChainP7b:          types.StringNull(),
+		TrustedCertificates: types.MapNull(types.StringType),
+		DestinationPath:     types.StringNull(),
+		FilePermission:      types.StringValue("0600"),
+		FileOwner:           types.StringNull(),
+		FileGroup:           types.StringNull(),
+		StoreInState:        types.BoolValue(true),
+	}
+	if err := data.SetKeystore(ctx, b64File, nil); err != nil {
+		resp.Diagnostics.AddError("Unable to move resource state", err.Error())
+		return
+	}
+
+	tflog.Trace(ctx, "moved tls_self_signed_cert into a keystore")
+
+	resp.Diagnostics.Append(resp.TargetState.Set(ctx, &data)...)
+}
+
+// movedKeystorePassword generates the password of a moved keystore.
+func movedKeystorePassword() (string, error) {
+	b, err := randomBytes(24)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// moveTLSPrivateKey explains how to move a tls_private_key, which has no
+// certificate to put into a keystore.
+func moveTLSPrivateKey(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if !isTLSResource(req, "tls_private_key") {
+		return
+	}
+
+	resp.Diagnostics.AddError(
+		"Unable to move resource state",
+		"A tls_private_key has no certificate to put into a keystore. Move the tls_self_signed_cert "+
+			"using the key instead, which carries both, and drop the tls_private_key with a removed block.",
+	)
+}
+
+// subjectFromName returns the subject attribute for a certificate subject.
+// Only the first value of each field is kept.
+func subjectFromName(ctx context.Context, name pkix.Name) (types.Object, diag.Diagnostics) {
+	first := func(values []string) types.String {
+		if len(values) == 0 {
+			return types.StringNull()
+		}
+		return types.StringValue(values[0])
+	}
+
+	commonName := types.StringNull()
+	if name.CommonName != "" {
+		commonName = types.StringValue(name.CommonName)
+	}
+
+	return types.ObjectValueFrom(ctx, subjectAttrTypes, SubjectModel{
+		CommonName:         commonName,
+		Organization:       first(name.Organization),
+		OrganizationalUnit: first(name.OrganizationalUnit),
+		Locality:           first(name.Locality),
+		State:              first(name.Province),
+		Country:            first(name.Country),
+	})
+}
@@ -0,0 +1,370 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/compare"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccKeystoreResourceMoveFromTLS(t *testing.T) {
+	for _, algorithm := range []string{"RSA", "ECDSA"} {
+		t.Run(algorithm, func(t *testing.T) {
+			testAccKeystoreResourceMoveFromTLS(t, algorithm)
+		})
+	}
+}
+
+// testAccKeystoreResourceMoveFromTLS moves a tls_self_signed_cert with a key
+// of algorithm into a jks_keystore that leaves key_algorithm unset.
+func testAccKeystoreResourceMoveFromTLS(t *testing.T, algorithm string) {
+	tlsConfig := fmt.Sprintf(`
+resource "tls_private_key" "test" {
+  algorithm = %q
+}
+
+resource "tls_self_signed_cert" "test" {
+  private_key_pem       = tls_private_key.test.private_key_pem
+  validity_period_hours = 24
+  allowed_uses          = ["server_auth"]
+
+  subject {
+    common_name  = "service.example.com"
+    organization = "Example"
+  }
+}
+`, algorithm)
+	movedConfig := fmt.Sprintf(`
+resource "tls_private_key" "test" {
+  algorithm = %q
+}
+
+moved {
+  from = tls_self_signed_cert.test
+  to   = jks_keystore.test
+}
+
+resource "jks_keystore" "test" {
+  password = "MyPassword12345"
+  subject = {
+    common_name  = "service.example.com"
+    organization = "Example"
+  }
+}
+`, algorithm)
+	certificatesSame := statecheck.CompareValue(compare.ValuesSame())
+
+	resource.Test(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"tls": {Source: "hashicorp/tls"},
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: tlsConfig,
+				ConfigStateChecks: []statecheck.StateCheck{
+					certificatesSame.AddStateValue("tls_self_signed_cert.test", tfjsonpath.New("cert_pem")),
+				},
+			},
+			// The certificate is kept and the keystore re-encrypted in place
+			// with the configured password, whatever the key algorithm.
+			{
+				Config: movedConfig,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(TestResourceFullName, plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckKeystorePassword(TestResourceFullName, "MyPassword12345"),
+					resource.TestCheckResourceAttr(TestResourceFullName, "certificate_subject", "CN=service.example.com,O=Example"),
+				),
+				ConfigStateChecks: []statecheck.StateCheck{
+					certificatesSame.AddStateValue(TestResourceFullName, tfjsonpath.New("certificate_pem")),
+				},
+			},
+		},
+	})
+}
+
+// testTLSSelfSignedCertState returns a tls_self_signed_cert state for key
+// as the hashicorp/tls provider writes it, with a PKCS#1 RSA or SEC 1 EC
+// key.
+func testTLSSelfSignedCertState(t *testing.T, key crypto.Signer) (map[string]interface{}, *x509.Certificate) {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "service.example.com", Organization: []string{"Example"}, Country: []string{"NL"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var block *pem.Block
+	algorithm := "RSA"
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case *ecdsa.PrivateKey:
+		b, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
+		algorithm = "ECDSA"
+	default:
+		t.Fatalf("unsupported key %T", key)
+	}
+
+	return map[string]interface{}{
+		"id":                    "42",
+		"private_key_pem":       string(pem.EncodeToMemory(block)),
+		"cert_pem":              string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		"key_algorithm":         algorithm,
+		"validity_period_hours": 24,
+		"allowed_uses":          []string{"server_auth"},
+		"subject": []map[string]interface{}{{
+			"common_name":  "service.example.com",
+			"organization": "Example",
+			"country":      "NL",
+		}},
+	}, cert
+}
+
+// testRSAKey returns a new RSA key for a tls_self_signed_cert state.
+func testRSAKey(t *testing.T) crypto.Signer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// testECKey returns a new EC P-256 key for a tls_self_signed_cert state.
+func testECKey(t *testing.T) crypto.Signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// testMoveKeystoreState runs the movers of jks_keystore the way the
+// framework does for a moved block from sourceTypeName.
+func testMoveKeystoreState(t *testing.T, sourceTypeName string, sourceState map[string]interface{}) *fwresource.MoveStateResponse {
+	t.Helper()
+	ctx := context.Background()
+
+	raw, err := json.Marshal(sourceState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceRawState := &tfprotov6.RawState{JSON: raw}
+
+	var schemaResp fwresource.SchemaResponse
+	r := &KeystoreResource{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+
+	for _, mover := range r.MoveState(ctx) {
+		req := fwresource.MoveStateRequest{
+			SourceProviderAddress: "registry.terraform.io/hashicorp/tls",
+			SourceTypeName:        sourceTypeName,
+			SourceRawState:        sourceRawState,
+		}
+		if mover.SourceSchema != nil {
+			value, err := sourceRawState.UnmarshalWithOpts(mover.SourceSchema.Type().TerraformType(ctx), tfprotov6.UnmarshalOpts{
+				ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+			})
+			if err == nil {
+				req.SourceState = &tfsdk.State{Raw: value, Schema: *mover.SourceSchema}
+			}
+		}
+		resp := &fwresource.MoveStateResponse{
+			TargetState: tfsdk.State{
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+				Schema: schemaResp.Schema,
+			},
+		}
+		mover.StateMover(ctx, req, resp)
+		if resp.Diagnostics.HasError() || !resp.TargetState.Raw.IsNull() {
+			return resp
+		}
+	}
+	return nil
+}
+
+func TestMoveStateFromTLSSelfSignedCert(t *testing.T) {
+	for name, newKey := range map[string]func(*testing.T) crypto.Signer{
+		"rsa": testRSAKey,
+		"ec":  testECKey,
+	} {
+		t.Run(name, func(t *testing.T) {
+			sourceState, cert := testTLSSelfSignedCertState(t, newKey(t))
+
+			resp := testMoveKeystoreState(t, "tls_self_signed_cert", sourceState)
+			if resp == nil {
+				t.Fatal("expected tls_self_signed_cert to be moved")
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var moved KeystoreResourceModel
+			if diags := resp.TargetState.Get(context.Background(), &moved); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			expectedSubject := DistinguishedName{CommonName: "service.example.com", Organization: "Example", Country: "NL"}
+			if subject := SubjectDistinguishedName(moved.Subject); subject != expectedSubject {
+				t.Errorf("expected subject %+v, got %+v", expectedSubject, subject)
+			}
+			if !moved.KeyAlgorithm.IsNull() {
+				t.Errorf("expected key_algorithm to be null, got %s", moved.KeyAlgorithm)
+			}
+			if len(moved.Password.ValueString()) < MinPasswordLength {
+				t.Errorf("expected the keystore to be protected with a generated password, got %q", moved.Password.ValueString())
+			}
+			if moved.CertificatePem.ValueString() != sourceState["cert_pem"] {
+				t.Error("expected the certificate to be kept")
+			}
+			testCheckMovedKeystore(t, moved, moved.Password.ValueString(), cert)
+
+			// The RSA default of key_algorithm does not replace the moved
+			// keystore, and the next apply re-encrypts it with the
+			// configured password.
+			planned := moved
+			planned.Password = types.StringValue("MyPassword12345")
+			planned.KeyAlgorithm = types.StringValue(KeyAlgorithmRSA)
+			planned.KeyAlgorithm = testPlanKeyAlgorithm(t, moved, planned, types.StringNull())
+			if !planned.KeyAlgorithm.IsNull() {
+				t.Errorf("expected key_algorithm to be planned null, got %s", planned.KeyAlgorithm)
+			}
+
+			updated, diags := testUpdateKeystoreResource(t, testKeystoreResource(t, newFakeKeytool(t)), moved, planned)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			testCheckMovedKeystore(t, updated, "MyPassword12345", cert)
+		})
+	}
+}
+
+// testPlanKeyAlgorithm runs the key_algorithm plan modifiers of jks_keystore
+// from prior to planned, with key_algorithm configured as config, and
+// returns the planned value. It fails the test if they require replacement.
+func testPlanKeyAlgorithm(t *testing.T, prior, planned KeystoreResourceModel, config types.String) types.String {
+	t.Helper()
+	ctx := context.Background()
+
+	state := testKeystoreResourceValue(t, prior)
+	plan := testKeystoreResourceValue(t, planned)
+	req := planmodifier.StringRequest{
+		Path:        path.Root("key_algorithm"),
+		ConfigValue: config,
+		PlanValue:   planned.KeyAlgorithm,
+		StateValue:  prior.KeyAlgorithm,
+		Plan:        tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw},
+		State:       state,
+	}
+	for _, modifier := range plan.Schema.(schema.Schema).Attributes["key_algorithm"].(schema.StringAttribute).PlanModifiers {
+		resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+		modifier.PlanModifyString(ctx, req, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+		}
+		if resp.RequiresReplace {
+			t.Fatal("expected key_algorithm not to require replacement")
+		}
+		req.PlanValue = resp.PlanValue
+	}
+	return req.PlanValue
+}
+
+// testCheckMovedKeystore checks that the keystore of data holds the key and
+// certificate of tls_self_signed_cert, protected with password.
+func testCheckMovedKeystore(t *testing.T, data KeystoreResourceModel, password string, cert *x509.Certificate) {
+	t.Helper()
+
+	b, err := base64.StdEncoding.DecodeString(data.File.ValueString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := DecodeKeystore(b, StoreTypePKCS12, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := ks.Entry(KeyAlias)
+	if !ok {
+		t.Fatalf("entry %s not found", KeyAlias)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(entry.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !PublicKeysEqual(publicKey(key), cert.PublicKey) || !entry.Certificates[0].Equal(cert) {
+		t.Error("expected the key and certificate of tls_self_signed_cert")
+	}
+}
+
+func TestMoveStateFromTLSPrivateKey(t *testing.T) {
+	resp := testMoveKeystoreState(t, "tls_private_key", map[string]interface{}{
+		"id":        "abc",
+		"algorithm": "RSA",
+	})
+	if resp == nil || !resp.Diagnostics.HasError() {
+		t.Error("expected moving tls_private_key to be rejected with an explanation")
+	}
+}
+
+func TestMoveStateFromOtherResource(t *testing.T) {
+	sourceState, _ := testTLSSelfSignedCertState(t, testRSAKey(t))
+
+	if resp := testMoveKeystoreState(t, "tls_locally_signed_cert", sourceState); resp != nil {
+		t.Errorf("expected tls_locally_signed_cert not to be handled, got %v", resp.Diagnostics)
+	}
+}
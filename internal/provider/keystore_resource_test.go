@@ -4,14 +4,32 @@
 package provider
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-testing/compare"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
 )
 
 type KeystoreTestContext struct {
@@ -54,7 +72,7 @@ func TestAccKeystoreResource(t *testing.T) {
 					testCheckResourceAttrLengthGreater(TestResourceFullName, "id", 0),
 					resource.TestCheckResourceAttr(TestResourceFullName, "password", model.Password),
 					testCheckResourceAttrLengthGreater(TestResourceFullName, "file", 1000),
-					resource.TestCheckResourceAttr(TestResourceFullName, "common_name", model.DistinguishedName.CommonName),
+					resource.TestCheckResourceAttr(TestResourceFullName, "subject.common_name", model.DistinguishedName.CommonName),
 				),
 				ConfigStateChecks: []statecheck.StateCheck{
 					valuesDifferCtx.AddStateValue(
@@ -91,6 +109,557 @@ func TestAccKeystoreResource(t *testing.T) {
 	})
 }
 
+func TestAccKeystoreResourceDestinationPath(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "keystore.p12")
+	config := fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = "MyPassword12345"
+    subject = {
+        common_name = "MyCommonName"
+    }
+    destination_path = %q
+    file_permission = "0640"
+}`, destination)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(s *terraform.State) error {
+			if _, err := os.Stat(destination); !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("expected %s to be removed, got %v", destination, err)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  testCheckDestinationFile(destination),
+			},
+			// A file changed outside of Terraform is rewritten
+			{
+				PreConfig: func() {
+					if err := os.WriteFile(destination, []byte("changed"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: config,
+				Check:  testCheckDestinationFile(destination),
+			},
+		},
+	})
+}
+
+func TestAccKeystoreResourceNotStoredInState(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "keystore.p12")
+	config := fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = "MyPassword12345"
+    subject = {
+        common_name = "MyCommonName"
+    }
+    destination_path = %q
+    store_in_state = false
+}`, destination)
+
+	idsDiffer := statecheck.CompareValue(compare.ValuesDiffer())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr(TestResourceFullName, "file"),
+					resource.TestCheckResourceAttr(TestResourceFullName, "certificate_subject", "CN=MyCommonName"),
+					testCheckResourceAttrLengthGreater(TestResourceFullName, "certificate_fingerprint_sha256", 63),
+					testCheckResourceAttrLengthGreater(TestResourceFullName, "certificate_not_after", 0),
+					resource.TestCheckResourceAttrWith(TestResourceFullName, "destination_sha256", func(value string) error {
+						data, err := os.ReadFile(destination)
+						if err != nil {
+							return err
+						}
+						if value != SHA256Hex(data) {
+							return fmt.Errorf("destination_sha256 is %s, expected %s", value, SHA256Hex(data))
+						}
+						return nil
+					}),
+				),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsDiffer.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+			},
+			// A missing file can only be recreated
+			{
+				PreConfig: func() {
+					if err := os.Remove(destination); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: config,
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsDiffer.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+			},
+		},
+	})
+}
+
+func TestAccKeystoreResourceWriteOnlyPassword(t *testing.T) {
+	config := func(passwordAttributes string) string {
+		return fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    subject = {
+        common_name = "MyCommonName"
+    }
+    %s
+}`, passwordAttributes)
+	}
+
+	idsSame := statecheck.CompareValue(compare.ValuesSame())
+	certificatesSame := statecheck.CompareValue(compare.ValuesSame())
+	destination := filepath.Join(t.TempDir(), "keystore.p12")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`password = "MyPassword12345"` + "\n" + `password_wo = "MyPassword12345"`),
+				ExpectError: regexp.MustCompile("Exactly one of password and password_wo must be set"),
+			},
+			{
+				Config: config(`password = "MyPassword12345"`),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+			},
+			// Switching to a write-only password re-encrypts the keystore in place
+			{
+				Config: config(`password_wo = "MyNewPassword12345"` + "\n" + `password_wo_version = 1`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckNotInState("MyPassword12345"),
+					testCheckNotInState("MyNewPassword12345"),
+					testCheckKeystorePassword(TestResourceFullName, "MyNewPassword12345"),
+				),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+					certificatesSame.AddStateValue(TestResourceFullName, tfjsonpath.New("certificate_fingerprint_sha256")),
+					statecheck.ExpectKnownValue(TestResourceFullName, tfjsonpath.New("password"), knownvalue.Null()),
+					statecheck.ExpectKnownValue(TestResourceFullName, tfjsonpath.New("password_wo"), knownvalue.Null()),
+				},
+			},
+			{
+				Config:      config(`password_wo = "MyRotatedPassword12345"` + "\n" + `password_wo_version = 2`),
+				ExpectError: regexp.MustCompile("previous_password_wo is not set"),
+			},
+			// Bumping the version rotates the password in place, keeping the key pair
+			{
+				Config: config(`password_wo = "MyRotatedPassword12345"` + "\n" + `password_wo_version = 2` + "\n" + `previous_password_wo = "MyNewPassword12345"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckNotInState("MyNewPassword12345"),
+					testCheckNotInState("MyRotatedPassword12345"),
+					testCheckKeystorePassword(TestResourceFullName, "MyRotatedPassword12345"),
+				),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+					certificatesSame.AddStateValue(TestResourceFullName, tfjsonpath.New("certificate_fingerprint_sha256")),
+					statecheck.ExpectKnownValue(TestResourceFullName, tfjsonpath.New("previous_password_wo"), knownvalue.Null()),
+				},
+			},
+			// Without a version change, a changed password_wo is not applied
+			{
+				Config: config(`password_wo = "MyOtherPassword12345"` + "\n" + `password_wo_version = 2` + "\n" +
+					fmt.Sprintf(`destination_path = %q`, destination)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckKeystorePassword(TestResourceFullName, "MyRotatedPassword12345"),
+					testCheckDestinationFile(destination),
+				),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+			},
+		},
+	})
+}
+
+func TestAccKeystoreResourceValidation(t *testing.T) {
+	config := func(attributes string) string {
+		return fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = "MyPassword12345"
+    %s
+}`, attributes)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "jks_keystore" "test" {
+    password = "short"
+}`,
+				ExpectError: regexp.MustCompile(`Attribute password string length must be at least 6`),
+			},
+			{
+				Config:      config(`subject = { country = "NLD" }`),
+				ExpectError: regexp.MustCompile(`Attribute subject.country must be a two-letter country code`),
+			},
+			{
+				Config:      config(`subject = {}`),
+				ExpectError: regexp.MustCompile(`At least one field of the subject must be set`),
+			},
+			{
+				Config:      config(`subject = { common_name = "" }`),
+				ExpectError: regexp.MustCompile(`Attribute subject.common_name string length must be at least 1`),
+			},
+			{
+				Config:      config(`key_algorithm = "DSA"`),
+				ExpectError: regexp.MustCompile(`Attribute key_algorithm value must be one of`),
+			},
+			{
+				Config:      config(`signature_algorithm = "SHA1withRSA"`),
+				ExpectError: regexp.MustCompile(`Attribute signature_algorithm value must be one of`),
+			},
+			{
+				Config:      config(`signature_algorithm = "SHA256withECDSA"`),
+				ExpectError: regexp.MustCompile(`signature_algorithm SHA256withECDSA does not apply to RSA keys`),
+			},
+			{
+				Config:      config(`key_algorithm = "Ed25519"` + "\n" + `signature_algorithm = "RSASSA-PSS"`),
+				ExpectError: regexp.MustCompile(`signature_algorithm RSASSA-PSS does not apply to Ed25519 keys`),
+			},
+			{
+				Config:      config(`pkcs12_encryption = "custom"`),
+				ExpectError: regexp.MustCompile(`mac_algorithm is required when pkcs12_encryption is custom`),
+			},
+			{
+				Config:      config(`mac_iterations = 20000`),
+				ExpectError: regexp.MustCompile(`mac_iterations only applies when pkcs12_encryption is custom`),
+			},
+			{
+				Config:      config(`ca_chain_p7b = "bm90IGEgYnVuZGxl"`),
+				ExpectError: regexp.MustCompile(`Invalid PKCS#7 certificate chain`),
+			},
+			{
+				Config:      config(`ca_chain_pem = ""` + "\n" + `ca_chain_p7b = ""`),
+				ExpectError: regexp.MustCompile(`These attributes cannot be configured together`),
+			},
+			{
+				Config:      config(`store_in_state = false`),
+				ExpectError: regexp.MustCompile(`destination_path is required when store_in_state is false`),
+			},
+			{
+				Config:      config(`trusted_certificates = { root = "" }` + "\n" + `subject = { common_name = "MyCommonName" }`),
+				ExpectError: regexp.MustCompile(`These attributes cannot be configured together`),
+			},
+		},
+	})
+}
+
+func TestAccKeystoreResourceKeyAlgorithm(t *testing.T) {
+	config := func(attributes string) string {
+		return fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = "MyPassword12345"
+    %s
+}`, attributes)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(TestResourceFullName, "key_algorithm", "RSA"),
+					testCheckCertificatePublicKey(TestResourceFullName, x509.RSA),
+				),
+			},
+			{
+				Config: config(`key_algorithm = "EC"`),
+				Check:  testCheckCertificatePublicKey(TestResourceFullName, x509.ECDSA),
+			},
+			{
+				Config: config(`key_algorithm = "EC"` + "\n" + `signature_algorithm = "SHA384withECDSA"`),
+				Check:  testCheckCertificateSignature(TestResourceFullName, x509.ECDSAWithSHA384),
+			},
+			{
+				Config: config(`signature_algorithm = "RSASSA-PSS"`),
+				Check:  testCheckCertificateSignature(TestResourceFullName, x509.SHA256WithRSAPSS),
+			},
+		},
+	})
+}
+
+func TestAccKeystoreResourcePKCS12Encryption(t *testing.T) {
+	config := func(attributes string) string {
+		return fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = "MyPassword12345"
+    %s
+}`, attributes)
+	}
+
+	// Changing the encryption re-encrypts the keystore in place.
+	idsSame := statecheck.CompareValue(compare.ValuesSame())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// An unset encryption is left to keytool.
+			{
+				Config: config(""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+				Check: resource.TestCheckNoResourceAttr(TestResourceFullName, "pkcs12_encryption"),
+			},
+			{
+				Config: config(`pkcs12_encryption = "modern"`),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+				Check: testCheckKeystoreProtection(TestResourceFullName, modernPKCS12Protection),
+			},
+			{
+				Config: config(`pkcs12_encryption = "legacy"`),
+				ConfigStateChecks: []statecheck.StateCheck{
+					idsSame.AddStateValue(TestResourceFullName, tfjsonpath.New("id")),
+				},
+				Check: testCheckKeystoreProtection(TestResourceFullName, legacyPKCS12Protection),
+			},
+			{
+				Config: config(`pkcs12_encryption = "custom"` + "\n" + `mac_algorithm = "HmacPBESHA1"` + "\n" + `mac_iterations = 2048`),
+				Check:  testCheckKeystoreProtection(TestResourceFullName, NewPKCS12Protection(PKCS12EncryptionCustom, MacAlgorithmSHA1, 2048)),
+			},
+		},
+	})
+}
+
+// testCheckKeystoreProtection checks the algorithms protecting the keystore
+// in the file attribute.
+func testCheckKeystoreProtection(resourceName string, expected PKCS12Protection) resource.TestCheckFunc {
+	return resource.TestCheckResourceAttrWith(resourceName, "file", func(value string) error {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		protection, err := inspectPKCS12Protection(decoded)
+		if err != nil {
+			return err
+		}
+		if protection != expected {
+			return fmt.Errorf("expected %+v, got %+v", expected, protection)
+		}
+		return nil
+	})
+}
+
+// testCheckCertificatePublicKey checks the key algorithm of the certificate
+// in certificate_pem.
+func testCheckCertificatePublicKey(resourceName string, algorithm x509.PublicKeyAlgorithm) resource.TestCheckFunc {
+	return resource.TestCheckResourceAttrWith(resourceName, "certificate_pem", func(value string) error {
+		certs, err := ParseCertificatesPEM(value)
+		if err != nil {
+			return err
+		}
+		if len(certs) != 1 || certs[0].PublicKeyAlgorithm != algorithm {
+			return fmt.Errorf("expected a single %s certificate, got %q", algorithm, value)
+		}
+		return nil
+	})
+}
+
+func testCheckCertificateSignature(resourceName string, algorithm x509.SignatureAlgorithm) resource.TestCheckFunc {
+	return resource.TestCheckResourceAttrWith(resourceName, "certificate_pem", func(value string) error {
+		certs, err := ParseCertificatesPEM(value)
+		if err != nil {
+			return err
+		}
+		if len(certs) != 1 || certs[0].SignatureAlgorithm != algorithm {
+			return fmt.Errorf("expected a single certificate signed with %s, got %q", algorithm, value)
+		}
+		return nil
+	})
+}
+
+// testCheckNotInState fails if any attribute of any resource in state
+// contains value.
+func testCheckNotInState(value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, module := range s.Modules {
+			for name, rs := range module.Resources {
+				for key, attr := range rs.Primary.Attributes {
+					if strings.Contains(attr, value) {
+						return fmt.Errorf("%s.%s contains a value that must not be stored in state", name, key)
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// testCheckKeystorePassword checks that the keystore in the file attribute
+// opens with password.
+func testCheckKeystorePassword(resourceName, password string) resource.TestCheckFunc {
+	return resource.TestCheckResourceAttrWith(resourceName, "file", func(value string) error {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		_, err = DecodeKeystore(decoded, StoreTypePKCS12, password)
+		return err
+	})
+}
+
+func testCheckDestinationFile(destination string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[TestResourceFullName]
+		if !ok {
+			return fmt.Errorf("not found: %s", TestResourceFullName)
+		}
+
+		data, err := os.ReadFile(destination)
+		if err != nil {
+			return err
+		}
+		if base64.StdEncoding.EncodeToString(data) != rs.Primary.Attributes["file"] {
+			return fmt.Errorf("%s does not contain the keystore in state", destination)
+		}
+		if digest := rs.Primary.Attributes["destination_sha256"]; digest != SHA256Hex(data) {
+			return fmt.Errorf("destination_sha256 is %s, expected %s", digest, SHA256Hex(data))
+		}
+		return nil
+	}
+}
+
+func TestAccKeystoreResourceTrustedCertificates(t *testing.T) {
+	rootPEM := testSelfSignedCertificatePEM(t, "Root CA")
+	config := func(attributes string) string {
+		return fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    trusted_certificates = {
+        root = %q
+    }
+    %s
+}`, rootPEM, attributes)
+	}
+	withoutPassword := config("")
+	withPassword := config(`password = "MyPassword12345"`)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Without a password, the keystore has neither an integrity MAC
+			// nor encrypted certificates.
+			{
+				Config: withoutPassword,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckKeystorePassword("jks_keystore.test", ""),
+					testCheckKeystoreProtection("jks_keystore.test", PKCS12Protection{}),
+					resource.TestCheckNoResourceAttr("jks_keystore.test", "key_algorithm"),
+					resource.TestCheckNoResourceAttr("jks_keystore.test", "certificate_pem"),
+					resource.TestCheckNoResourceAttr("jks_keystore.test", "certificate_request_pem"),
+				),
+			},
+			// Setting a password encodes the keystore again in place.
+			{
+				Config: withPassword,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("jks_keystore.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: testCheckKeystorePassword("jks_keystore.test", "MyPassword12345"),
+			},
+			{
+				Config:      config(`pkcs12_encryption = "modern"`),
+				ExpectError: regexp.MustCompile(`pkcs12_encryption requires a password`),
+			},
+		},
+	})
+}
+
+func TestAccKeystoreResourceCertificateReply(t *testing.T) {
+	model := KeystoreModel{
+		DistinguishedName: DistinguishedName{
+			CommonName: "MyCommonName",
+		},
+		Password: "MyPassword12345",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A certificate reply cannot match a key pair that is yet to be
+			// generated.
+			{
+				Config: fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = %q
+    subject = {
+        common_name = %q
+    }
+    signed_certificate_pem = <<EOT
+%sEOT
+}`, model.Password, model.DistinguishedName.CommonName, testSelfSignedCertificatePEM(t, "other")),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Certificate reply on create"),
+			},
+			{
+				Config: ToTfResourceString(model),
+				Check:  resource.TestMatchResourceAttr(TestResourceFullName, "certificate_request_pem", regexp.MustCompile("^-----BEGIN CERTIFICATE REQUEST-----\n")),
+			},
+			// A certificate issued for another key must be rejected
+			{
+				Config: fmt.Sprintf(`
+resource "jks_keystore" "test" {
+    password = %q
+    subject = {
+        common_name = %q
+    }
+    signed_certificate_pem = <<EOT
+%sEOT
+}`, model.Password, model.DistinguishedName.CommonName, testSelfSignedCertificatePEM(t, "other")),
+				ExpectError: regexp.MustCompile("does not match the private key"),
+			},
+		},
+	})
+}
+
+// testSelfSignedCertificatePEM returns a PEM encoded self-signed certificate
+// for a freshly generated key.
+func testSelfSignedCertificatePEM(t *testing.T, commonName string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
 func testCheckKeepEncodedFile() resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rootModule := s.RootModule()
@@ -139,12 +708,14 @@ func ToTfResourceString(m KeystoreModel) string {
 	return fmt.Sprintf(`
 resource "jks_keystore" "test" {
     password = %q
-    common_name = %q
-    organization = %q
-    organizational_unit = %q
-    locality = %q
-    state = %q
-    country = %q
+    subject = {
+        common_name = %q
+        organization = %q
+        organizational_unit = %q
+        locality = %q
+        state = %q
+        country = %q
+    }
 }`,
 
 		m.Password,
@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// KeystoreResourceModelV0 describes the data model of schema version 0,
+// which had the distinguished name in flat attributes.
+type KeystoreResourceModelV0 struct {
+	Id                 types.String `tfsdk:"id"`
+	Password           types.String `tfsdk:"password"`
+	PasswordWo         types.String `tfsdk:"password_wo"`
+	PasswordWoVersion  types.Int64  `tfsdk:"password_wo_version"`
+	File               types.String `tfsdk:"file"`
+	CommonName         types.String `tfsdk:"common_name"`
+	Organization       types.String `tfsdk:"organization"`
+	OrganizationalUnit types.String `tfsdk:"organizational_unit"`
+	Locality           types.String `tfsdk:"locality"`
+	State              types.String `tfsdk:"state"`
+	Country            types.String `tfsdk:"country"`
+	SignedCertificate  types.String `tfsdk:"signed_certificate_pem"`
+	CaChain            types.String `tfsdk:"ca_chain_pem"`
+	DestinationPath    types.String `tfsdk:"destination_path"`
+	FilePermission     types.String `tfsdk:"file_permission"`
+	FileOwner          types.String `tfsdk:"file_owner"`
+	FileGroup          types.String `tfsdk:"file_group"`
+	DestinationSha256  types.String `tfsdk:"destination_sha256"`
+	StoreInState       types.Bool   `tfsdk:"store_in_state"`
+
+	CertificateSubject           types.String `tfsdk:"certificate_subject"`
+	CertificateFingerprintSha256 types.String `tfsdk:"certificate_fingerprint_sha256"`
+	CertificateNotAfter          types.String `tfsdk:"certificate_not_after"`
+}
+
+// keystoreResourceSchemaV0 is schema version 0 of jks_keystore, reduced to
+// what is needed to read prior state.
+func keystoreResourceSchemaV0() *schema.Schema {
+	optional := func() schema.StringAttribute { return schema.StringAttribute{Optional: true} }
+	computed := func() schema.StringAttribute { return schema.StringAttribute{Computed: true} }
+
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":                             computed(),
+			"password":                       optional(),
+			"password_wo":                    schema.StringAttribute{Optional: true, WriteOnly: true},
+			"password_wo_version":            schema.Int64Attribute{Optional: true},
+			"file":                           computed(),
+			"common_name":                    optional(),
+			"organization":                   optional(),
+			"organizational_unit":            optional(),
+			"locality":                       optional(),
+			"state":                          optional(),
+			"country":                        optional(),
+			"signed_certificate_pem":         optional(),
+			"ca_chain_pem":                   optional(),
+			"destination_path":               optional(),
+			"file_permission":                schema.StringAttribute{Optional: true, Computed: true},
+			"file_owner":                     optional(),
+			"file_group":                     optional(),
+			"destination_sha256":             computed(),
+			"store_in_state":                 schema.BoolAttribute{Optional: true, Computed: true},
+			"certificate_subject":            computed(),
+			"certificate_fingerprint_sha256": computed(),
+			"certificate_not_after":          computed(),
+		},
+	}
+}
+
+func (r *KeystoreResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   keystoreResourceSchemaV0(),
+			StateUpgrader: upgradeKeystoreStateV0,
+		},
+	}
+}
+
+// upgradeKeystoreStateV0 nests the flat distinguished name attributes into
+// subject and derives the certificate attributes from the keystore in
+// state, which older states do not have. Version 0 always generated 2048
+// bit RSA keys.
+func upgradeKeystoreStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior KeystoreResourceModelV0
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	subject := types.ObjectNull(subjectAttrTypes)
+	dn := []types.String{prior.CommonName, prior.Organization, prior.OrganizationalUnit, prior.Locality, prior.State, prior.Country}
+	if slices.ContainsFunc(dn, func(v types.String) bool { return !v.IsNull() }) {
+		value, diags := types.ObjectValueFrom(ctx, subjectAttrTypes, SubjectModel{
+			CommonName:         prior.CommonName,
+			Organization:       prior.Organization,
+			OrganizationalUnit: prior.OrganizationalUnit,
+			Locality:           prior.Locality,
+			State:              prior.State,
+			Country:            prior.Country,
+		})
+		resp.Diagnostics.Append(diags...)
+		subject = value
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	upgraded := KeystoreResourceModel{
+		Id:                           prior.Id,
+		Password:                     prior.Password,
+		PasswordWo:                   types.StringNull(),
+		PasswordWoVersion:            prior.PasswordWoVersion,
+		PreviousPasswordWo:           types.StringNull(),
+		File:                         prior.File,
+		Subject:                      subject,
+		KeyAlgorithm:                 types.StringValue(KeyAlgorithmRSA),
+		SignatureAlgorithm:           types.StringNull(),
+		Pkcs12Encryption:             types.StringNull(),
+		MacAlgorithm:                 types.StringNull(),
+		MacIterations:                types.Int64Null(),
+		SignedCertificate:            prior.SignedCertificate,
+		CaChain:                      prior.CaChain,
+		CaChainP7b:                   types.StringNull(),
+		TrustedCertificates:          types.MapNull(types.StringType),
+		DestinationPath:              prior.DestinationPath,
+		FilePermission:               prior.FilePermission,
+		FileOwner:                    prior.FileOwner,
+		FileGroup:                    prior.FileGroup,
+		DestinationSha256:            prior.DestinationSha256,
+		StoreInState:                 prior.StoreInState,
+		CertificateSubject:           prior.CertificateSubject,
+		CertificateFingerprintSha256: prior.CertificateFingerprintSha256,
+		CertificateNotAfter:          prior.CertificateNotAfter,
+		CertificatePem:               types.StringNull(),
+		CertificateChainPem:          types.StringNull(),
+		CertificateChainP7b:          types.StringNull(),
+		CertificateRequestPem:        types.StringNull(),
+	}
+
+	// Attributes added after the resource was first released are missing
+	// from older states.
+	if upgraded.FilePermission.IsNull() {
+		upgraded.FilePermission = types.StringValue("0600")
+	}
+	if upgraded.StoreInState.IsNull() {
+		upgraded.StoreInState = types.BoolValue(true)
+	}
+
+	// The certificate can only be read from a keystore kept in state and
+	// encrypted with a password that is not write-only. Otherwise the
+	// certificate attributes stay null until the keystore content changes.
+	if !prior.File.IsNull() && !prior.Password.IsNull() {
+		decoded, err := base64.StdEncoding.DecodeString(prior.File.ValueString())
+		if err == nil {
+			err = upgraded.SetCertificate(ctx, decoded)
+		}
+		if err != nil {
+			tflog.Warn(ctx, "unable to read the certificate from the keystore in state", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &upgraded)...)
+}
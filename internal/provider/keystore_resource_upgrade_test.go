@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testUpgradeKeystoreStateV0 runs the version 0 upgrader on a state fixture.
+func testUpgradeKeystoreStateV0(t *testing.T, name string) KeystoreResourceModel {
+	t.Helper()
+	ctx := context.Background()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priorSchema := keystoreResourceSchemaV0()
+	priorState, err := (&tfprotov6.RawState{JSON: data}).Unmarshal(priorSchema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schemaResp resource.SchemaResponse
+	(&KeystoreResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Schema.Version != 1 {
+		t.Fatalf("expected schema version 1, got %d", schemaResp.Schema.Version)
+	}
+
+	req := resource.UpgradeStateRequest{
+		State: &tfsdk.State{Raw: priorState, Schema: priorSchema},
+	}
+	resp := resource.UpgradeStateResponse{
+		State: tfsdk.State{
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			Schema: schemaResp.Schema,
+		},
+	}
+	upgradeKeystoreStateV0(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var upgraded KeystoreResourceModel
+	if diags := resp.State.Get(ctx, &upgraded); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	return upgraded
+}
+
+func TestUpgradeKeystoreStateV0(t *testing.T) {
+	upgraded := testUpgradeKeystoreStateV0(t, "keystore-state-v0-initial.json")
+
+	expectedSubject := DistinguishedName{
+		CommonName:         "MyCommonName",
+		Organization:       "Example",
+		OrganizationalUnit: "Platform",
+		Locality:           "Amsterdam",
+		State:              "Noord-Holland",
+		Country:            "NL",
+	}
+	if subject := SubjectDistinguishedName(upgraded.Subject); subject != expectedSubject {
+		t.Errorf("expected subject %+v, got %+v", expectedSubject, subject)
+	}
+	if upgraded.Password.ValueString() != "changeit" || upgraded.File.IsNull() {
+		t.Error("expected password and file to be kept")
+	}
+	if upgraded.KeyAlgorithm.ValueString() != KeyAlgorithmRSA {
+		t.Errorf("expected the default RSA key pair, got %s", upgraded.KeyAlgorithm)
+	}
+	if upgraded.FilePermission.ValueString() != "0600" || !upgraded.StoreInState.ValueBool() {
+		t.Errorf("expected defaults for attributes missing from the state, got %s and %s", upgraded.FilePermission, upgraded.StoreInState)
+	}
+
+	// The certificate attributes are derived from the keystore in state.
+	if subject := upgraded.CertificateSubject.ValueString(); subject != "CN=MyCommonName,OU=Platform,O=Example,L=Amsterdam,ST=Noord-Holland,C=NL" {
+		t.Errorf("unexpected certificate subject %s", subject)
+	}
+	if fingerprint := upgraded.CertificateFingerprintSha256.ValueString(); fingerprint != "aab9b4bb0a139959d4c73e1b2bfdfd0ed347bb8b16a5793ac972126e2af77542" {
+		t.Errorf("unexpected certificate fingerprint %s", fingerprint)
+	}
+	if notAfter := upgraded.CertificateNotAfter.ValueString(); notAfter != "2051-10-12T12:34:14Z" {
+		t.Errorf("unexpected certificate expiry %s", notAfter)
+	}
+	block, rest := pem.Decode([]byte(upgraded.CertificatePem.ValueString()))
+	if block == nil || block.Type != "CERTIFICATE" || len(rest) != 0 {
+		t.Errorf("expected a single certificate, got %q", upgraded.CertificatePem.ValueString())
+	}
+	if upgraded.CertificateChainPem != upgraded.CertificatePem {
+		t.Error("expected the chain of a self-signed certificate to be the certificate")
+	}
+}
+
+func TestUpgradeKeystoreStateV0WriteOnly(t *testing.T) {
+	upgraded := testUpgradeKeystoreStateV0(t, "keystore-state-v0-write-only.json")
+
+	if !upgraded.Subject.IsNull() {
+		t.Errorf("expected a null subject, got %s", upgraded.Subject)
+	}
+	if upgraded.PasswordWoVersion != types.Int64Value(1) {
+		t.Errorf("expected password_wo_version to be kept, got %s", upgraded.PasswordWoVersion)
+	}
+	if upgraded.FilePermission.ValueString() != "0640" || upgraded.StoreInState.ValueBool() {
+		t.Errorf("expected file_permission and store_in_state to be kept, got %s and %s", upgraded.FilePermission, upgraded.StoreInState)
+	}
+	if upgraded.CertificateFingerprintSha256.ValueString() != "aab9b4bb0a139959d4c73e1b2bfdfd0ed347bb8b16a5793ac972126e2af77542" {
+		t.Error("expected the certificate attributes in state to be kept")
+	}
+
+	// Without the keystore in state the certificate cannot be read.
+	if !upgraded.CertificatePem.IsNull() || !upgraded.CertificateChainPem.IsNull() {
+		t.Error("expected certificate_pem and certificate_chain_pem to be null")
+	}
+}
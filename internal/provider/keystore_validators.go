@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// MinPasswordLength is the shortest keystore password keytool accepts.
+const MinPasswordLength = 6
+
+// nonEmptySubject rejects a subject without any field set, which keytool
+// cannot turn into a distinguished name.
+type nonEmptySubject struct{}
+
+func (v nonEmptySubject) Description(ctx context.Context) string {
+	return "At least one field of the subject must be set."
+}
+
+func (v nonEmptySubject) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v nonEmptySubject) ValidateObject(ctx context.Context, req validator.ObjectRequest, resp *validator.ObjectResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for _, value := range req.ConfigValue.Attributes() {
+		if !value.IsNull() {
+			return
+		}
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid subject",
+		"At least one field of the subject must be set, or subject must be left out.",
+	)
+}
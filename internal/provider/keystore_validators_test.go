@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestNonEmptySubject(t *testing.T) {
+	subject := func(commonName types.String) types.Object {
+		attributes := map[string]attr.Value{}
+		for name := range subjectAttrTypes {
+			attributes[name] = types.StringNull()
+		}
+		attributes["common_name"] = commonName
+		return types.ObjectValueMust(subjectAttrTypes, attributes)
+	}
+
+	tests := map[string]struct {
+		value     types.Object
+		expectErr bool
+	}{
+		"null":          {value: types.ObjectNull(subjectAttrTypes)},
+		"unknown":       {value: types.ObjectUnknown(subjectAttrTypes)},
+		"set":           {value: subject(types.StringValue("MyCommonName"))},
+		"unknown field": {value: subject(types.StringUnknown())},
+		"empty":         {value: subject(types.StringNull()), expectErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := &validator.ObjectResponse{}
+			nonEmptySubject{}.ValidateObject(context.Background(), validator.ObjectRequest{
+				Path:        path.Root("subject"),
+				ConfigValue: test.value,
+			}, resp)
+
+			if resp.Diagnostics.HasError() != test.expectErr {
+				t.Errorf("expected error %t, got %v", test.expectErr, resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// VerifyHostname checks that cert is valid for hostname, which may also be
+// an IP address.
+func VerifyHostname(cert *x509.Certificate, hostname string) error {
+	if err := cert.VerifyHostname(hostname); err != nil {
+		return fmt.Errorf("certificate %s is not valid for %s: %s", cert.Subject, hostname, err)
+	}
+	return nil
+}
+
+// VerifyChain verifies the certificate chain of every key entry of ks at
+// the given time, the way a Java trust manager would with the trusted
+// certificates of truststore. The errors of all entries are joined.
+func VerifyChain(ks, truststore *DecodedKeystore, now time.Time) error {
+	roots := x509.NewCertPool()
+	trusted := 0
+	for _, entry := range truststore.Entries {
+		if entry.Type == EntryTypeTrustedCertificate {
+			roots.AddCert(entry.Certificates[0])
+			trusted++
+		}
+	}
+	if trusted == 0 {
+		return errors.New("truststore contains no trusted certificates")
+	}
+
+	var errs []error
+	verified := 0
+	for _, entry := range ks.Entries {
+		if entry.Type != EntryTypePrivateKey {
+			continue
+		}
+		if len(entry.Certificates) == 0 {
+			errs = append(errs, fmt.Errorf("entry %q has no certificate chain", entry.Alias))
+			continue
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range entry.Certificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := entry.Certificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   now,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %q: %s", entry.Alias, err))
+			continue
+		}
+		verified++
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if verified == 0 {
+		return errors.New("keystore contains no private key entries")
+	}
+	return nil
+}
+
+// KeyMatchesCertificate checks that the private key of entry belongs to
+// the public key of cert.
+func KeyMatchesCertificate(entry *KeystoreEntry, cert *x509.Certificate) error {
+	if entry.Type != EntryTypePrivateKey {
+		return fmt.Errorf("entry %q is a %s, not a %s", entry.Alias, entry.Type, EntryTypePrivateKey)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(entry.Key)
+	if err != nil {
+		return fmt.Errorf("error parsing the private key of entry %q: %s", entry.Alias, err)
+	}
+	if !PublicKeysEqual(cert.PublicKey, publicKey(key)) {
+		return fmt.Errorf("the private key of entry %q does not match the public key of certificate %s", entry.Alias, cert.Subject)
+	}
+	return nil
+}
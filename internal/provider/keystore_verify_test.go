@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// go-pkcs12-truststore.p12 was written by software.sslmate.com/src/go-pkcs12
+// with the password "changeit" and holds the "Test CA" certificate that
+// issued the certificate of openssl-modern.p12, trusted under "test ca".
+func TestVerifyChain(t *testing.T) {
+	ks, err := InspectKeystore(context.Background(), testFixtureBase64(t, "testdata/openssl-modern.p12"), StoreTypePKCS12, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, err := InspectKeystore(context.Background(), testFixtureBase64(t, "testdata/go-pkcs12-truststore.p12"), StoreTypePKCS12, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := ks.Entries[0].Certificates[0]
+
+	if err := VerifyChain(ks, ts, leaf.NotBefore.Add(time.Hour)); err != nil {
+		t.Errorf("expected the chain to be trusted, got %s", err)
+	}
+	if err := VerifyChain(ks, ts, leaf.NotAfter.Add(time.Hour)); err == nil {
+		t.Error("expected an expired chain to fail")
+	}
+	if err := VerifyChain(ks, ks, leaf.NotBefore.Add(time.Hour)); err == nil {
+		t.Error("expected a truststore without trusted certificates to fail")
+	}
+	if err := VerifyChain(ts, ts, leaf.NotBefore.Add(time.Hour)); err == nil {
+		t.Error("expected a keystore without private keys to fail")
+	}
+}
+
+func TestVerifyHostname(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		DNSNames:     []string{"api.example.com", "*.apps.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hostname := range []string{"api.example.com", "web.apps.example.com", "10.0.0.1"} {
+		if err := VerifyHostname(cert, hostname); err != nil {
+			t.Errorf("expected %s to match, got %s", hostname, err)
+		}
+	}
+	for _, hostname := range []string{"example.com", "a.b.apps.example.com", "10.0.0.2"} {
+		if err := VerifyHostname(cert, hostname); err == nil {
+			t.Errorf("expected %s not to match", hostname)
+		}
+	}
+
+	// Like current TLS clients, the common name alone is not enough.
+	leaf := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server").Certificates[0]
+	if err := VerifyHostname(leaf, "leaf.example.com"); err == nil {
+		t.Error("expected a certificate without subject alternative names not to match")
+	}
+}
+
+func TestKeyMatchesCertificate(t *testing.T) {
+	entry := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+
+	if err := KeyMatchesCertificate(entry, entry.Certificates[0]); err != nil {
+		t.Errorf("expected the key to match the leaf certificate, got %s", err)
+	}
+	if err := KeyMatchesCertificate(entry, entry.Certificates[1]); err == nil {
+		t.Error("expected the key not to match the issuer certificate")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &LocallySignedCertResource{}
+
+func NewLocallySignedCertResource() resource.Resource {
+	return &LocallySignedCertResource{}
+}
+
+// LocallySignedCertResource defines the resource implementation.
+type LocallySignedCertResource struct {
+	runner KeytoolRunner
+}
+
+// LocallySignedCertResourceModel describes the resource data model.
+type LocallySignedCertResourceModel struct {
+	Id               types.String `tfsdk:"id"`
+	CaKeystore       types.String `tfsdk:"ca_keystore"`
+	CaPassword       types.String `tfsdk:"ca_password"`
+	CaAlias          types.String `tfsdk:"ca_alias"`
+	CertRequestPem   types.String `tfsdk:"cert_request_pem"`
+	ValidityDays     types.Int64  `tfsdk:"validity_days"`
+	KeyUsage         types.List   `tfsdk:"key_usage"`
+	ExtendedKeyUsage types.List   `tfsdk:"extended_key_usage"`
+	DnsNames         types.List   `tfsdk:"dns_names"`
+	IpAddresses      types.List   `tfsdk:"ip_addresses"`
+	CertPem          types.String `tfsdk:"cert_pem"`
+}
+
+func (r *LocallySignedCertResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_locally_signed_cert"
+}
+
+func (r *LocallySignedCertResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		Description: `
+        Signs a PEM encoded certificate request with a CA key held in a keystore using the keytool utility.
+        The machine running Terraform needs to have the keytool utility installed.
+        Changing any argument issues a new certificate.
+        `,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Generated UUID for the certificate",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ca_keystore": schema.StringAttribute{
+				Description: "Base64 encoded keystore holding the CA key, such as the file attribute of a jks_keystore",
+				Required:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ca_password": schema.StringAttribute{
+				Description: "Password for the CA keystore and its key",
+				Required:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ca_alias": schema.StringAttribute{
+				Description: "Alias of the CA key entry in the keystore. Defaults to the alias used by jks_keystore",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(KeyAlias),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"cert_request_pem": schema.StringAttribute{
+				Description: "PEM encoded certificate request to sign",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"validity_days": schema.Int64Attribute{
+				Description: "Number of days the certificate is valid for. Defaults to 365",
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(365),
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"key_usage": schema.ListAttribute{
+				Description: "Key usages in keytool notation, such as digitalSignature or keyEncipherment",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"extended_key_usage": schema.ListAttribute{
+				Description: "Extended key usages in keytool notation, such as serverAuth or clientAuth, or OIDs",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"dns_names": schema.ListAttribute{
+				Description: "DNS subject alternative names. Together with ip_addresses, replaces the names in the request when set",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"ip_addresses": schema.ListAttribute{
+				Description: "IP address subject alternative names. Together with dns_names, replaces the names in the request when set",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"cert_pem": schema.StringAttribute{
+				Description: "PEM encoded signed certificate",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *LocallySignedCertResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	runner, diags := keytoolRunnerFromProviderData(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.runner = runner
+}
+
+func (r *LocallySignedCertResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data LocallySignedCertResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	opts := SigningOptions{
+		ValidityDays: data.ValidityDays.ValueInt64(),
+	}
+	resp.Diagnostics.Append(data.KeyUsage.ElementsAs(ctx, &opts.KeyUsage, false)...)
+	resp.Diagnostics.Append(data.ExtendedKeyUsage.ElementsAs(ctx, &opts.ExtendedKeyUsage, false)...)
+	resp.Diagnostics.Append(data.DnsNames.ElementsAs(ctx, &opts.DNSNames, false)...)
+	resp.Diagnostics.Append(data.IpAddresses.ElementsAs(ctx, &opts.IPAddresses, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ca := KeystoreModel{
+		Password: data.CaPassword.ValueString(),
+		File:     data.CaKeystore.ValueString(),
+		Runner:   r.runner,
+	}
+
+	certPem, err := ca.SignCertificateRequest(ctx, data.CaAlias.ValueString(), data.CertRequestPem.ValueString(), opts)
+	if err != nil {
+		addKeystoreError(&resp.Diagnostics, "create", err, keystoreErrorPaths{
+			ErrIncorrectPassword: path.Root("ca_password"),
+			ErrCorruptKeystore:   path.Root("ca_keystore"),
+			ErrAliasNotFound:     path.Root("ca_alias"),
+		})
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+	data.CertPem = types.StringValue(certPem)
+
+	tflog.Trace(ctx, "signed a certificate request")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *LocallySignedCertResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data LocallySignedCertResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *LocallySignedCertResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data LocallySignedCertResourceModel
+
+	// Every argument requires replacement, so there is nothing to apply
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *LocallySignedCertResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data LocallySignedCertResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
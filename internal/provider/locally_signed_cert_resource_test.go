@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+const TestLocallySignedCertFullName = "jks_locally_signed_cert.test"
+
+func TestAccLocallySignedCertResource(t *testing.T) {
+	csr := testCertificateRequestPEM(t, "service.example.com")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccLocallySignedCertConfig(csr, `dns_names = ["service.example.com", "alt.example.com"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckResourceAttrLengthGreater(TestLocallySignedCertFullName, "id", 0),
+					resource.TestCheckResourceAttr(TestLocallySignedCertFullName, "ca_alias", KeyAlias),
+					resource.TestCheckResourceAttr(TestLocallySignedCertFullName, "validity_days", "30"),
+					testCheckSignedCertificate("MyCA", "alt.example.com"),
+				),
+			},
+		},
+	})
+}
+
+func testAccLocallySignedCertConfig(csr, extra string) string {
+	return fmt.Sprintf(`
+resource "jks_keystore" "ca" {
+    password = "MyPassword12345"
+    common_name = "MyCA"
+}
+
+resource "jks_locally_signed_cert" "test" {
+    ca_keystore = jks_keystore.ca.file
+    ca_password = jks_keystore.ca.password
+    validity_days = 30
+    key_usage = ["digitalSignature", "keyEncipherment"]
+    extended_key_usage = ["serverAuth"]
+    cert_request_pem = <<EOT
+%sEOT
+    %s
+}`, csr, extra)
+}
+
+func testCheckSignedCertificate(issuer, dnsName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[TestLocallySignedCertFullName]
+		if !ok {
+			return fmt.Errorf("not found: %s", TestLocallySignedCertFullName)
+		}
+
+		certs, err := ParseCertificatesPEM(rs.Primary.Attributes["cert_pem"])
+		if err != nil {
+			return err
+		}
+		if len(certs) != 1 {
+			return fmt.Errorf("expected one certificate, found %d", len(certs))
+		}
+		if certs[0].Issuer.CommonName != issuer {
+			return fmt.Errorf("issuer is %s, expected %s", certs[0].Issuer.CommonName, issuer)
+		}
+		if !slices.Contains(certs[0].DNSNames, dnsName) {
+			return fmt.Errorf("DNS names %v do not contain %s", certs[0].DNSNames, dnsName)
+		}
+
+		return nil
+	}
+}
+
+// testCertificateRequestPEM returns a PEM encoded certificate request for a
+// freshly generated key.
+func testCertificateRequestPEM(t *testing.T, commonName string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: []string{commonName},
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
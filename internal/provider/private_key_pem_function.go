@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &PrivateKeyPemFunction{}
+
+func NewPrivateKeyPemFunction() function.Function {
+	return &PrivateKeyPemFunction{}
+}
+
+// PrivateKeyPemFunction defines the function implementation.
+type PrivateKeyPemFunction struct {
+}
+
+func (f *PrivateKeyPemFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "private_key_pem"
+}
+
+func (f *PrivateKeyPemFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Extracts the private key of a keystore entry as PEM",
+		Description: "Decodes a base64 encoded PKCS12, JKS or JCEKS keystore, such as the file attribute of a jks_keystore, detecting its type, and returns the private key of the entry with the given alias as an unencrypted PKCS#8 PEM block, as expected in the tls.key of a Kubernetes TLS secret.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "keystore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS keystore",
+			},
+			function.StringParameter{
+				Name:        "password",
+				Description: "Password for the keystore and its keys",
+			},
+			function.StringParameter{
+				Name:        "alias",
+				Description: "Alias of the entry, matched ignoring case",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *PrivateKeyPemFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var keystore, password, alias string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &keystore, &password, &alias))
+
+	if resp.Error != nil {
+		return
+	}
+
+	ks, funcErr := inspectKeystoreArguments(ctx, keystore, "", password)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	entry, funcErr := keystoreEntryArgument(ks, alias)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	if entry.Type != EntryTypePrivateKey {
+		resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("entry %q is a %s, not a %s", entry.Alias, entry.Type, EntryTypePrivateKey))
+		return
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: entry.Key})
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, string(keyPEM)))
+}
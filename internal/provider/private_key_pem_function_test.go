@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccPrivateKeyPemFunction(t *testing.T) {
+	keystore := testFixtureBase64(t, "testdata/openssl-modern.p12")
+	entry := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	jks := base64.StdEncoding.EncodeToString(testEncodeJKS(t, StoreTypeJKS, []KeystoreEntry{*entry}, "changeit"))
+	config := func(keystore, password, alias string) string {
+		return fmt.Sprintf(`
+output "test" {
+  value = provider::jks::private_key_pem(%q, %q, %q)
+}
+`, keystore, password, alias)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(keystore, "changeit", "SERVER"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.StringExact(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: entry.Key})))),
+				},
+			},
+			{
+				Config:      config(keystore, "wrong-password", "server"),
+				ExpectError: regexp.MustCompile("keystore password was incorrect"),
+			},
+			{
+				Config:      config(keystore, "changeit", "missing"),
+				ExpectError: regexp.MustCompile(`alias "missing" not found in keystore`),
+			},
+			// The store type is detected.
+			{
+				Config: config(jks, "changeit", "server"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.StringExact(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: entry.Key})))),
+				},
+			},
+		},
+	})
+}
@@ -7,18 +7,25 @@ import (
 	"context"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 )
 
 var _ provider.Provider = &KeystoreProvider{}
+var _ provider.ProviderWithEphemeralResources = &KeystoreProvider{}
+var _ provider.ProviderWithFunctions = &KeystoreProvider{}
 
 type KeystoreProvider struct {
 	// version is set to the provider version on release, "dev" when the
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
 	version string
+
+	// runner runs keytool for the resources of the provider.
+	runner KeytoolRunner
 }
 
 // KeystoreProviderModel describes the provider data model.
@@ -46,14 +53,17 @@ func (p *KeystoreProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
-	// Configuration values are now available.
-	// if data.Endpoint.IsNull() { /* ... */ }
-
+	resp.ResourceData = p.runner
+	resp.EphemeralResourceData = p.runner
 }
 
 func (p *KeystoreProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewKeystoreResource,
+		NewLocallySignedCertResource,
+		NewKeystoreConversionResource,
+		NewTruststoreResource,
+		NewKeystoreEntryResource,
 	}
 }
 
@@ -63,10 +73,30 @@ func (p *KeystoreProvider) DataSources(ctx context.Context) []func() datasource.
 	}
 }
 
+func (p *KeystoreProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewKeystoreEphemeralResource,
+	}
+}
+
+func (p *KeystoreProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewDecodeKeystoreFunction,
+		NewAliasesFunction,
+		NewCertificatePemFunction,
+		NewPrivateKeyPemFunction,
+		NewChainPemFunction,
+		NewVerifyHostnameFunction,
+		NewVerifyChainFunction,
+		NewKeyMatchesCertificateFunction,
+	}
+}
+
 func New(version string) func() provider.Provider {
 	return func() provider.Provider {
 		return &KeystoreProvider{
 			version: version,
+			runner:  ExecKeytoolRunner{},
 		}
 	}
 }
@@ -0,0 +1,352 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &TruststoreResource{}
+var _ resource.ResourceWithConfigValidators = &TruststoreResource{}
+
+func NewTruststoreResource() resource.Resource {
+	return &TruststoreResource{}
+}
+
+// TruststoreResource defines the resource implementation.
+type TruststoreResource struct {
+}
+
+// TruststoreResourceModel describes the resource data model.
+type TruststoreResourceModel struct {
+	Id                     types.String `tfsdk:"id"`
+	Certificates           types.Map    `tfsdk:"certificates"`
+	CaChainP7b             types.String `tfsdk:"ca_chain_p7b"`
+	IncludeCacertsPath     types.String `tfsdk:"include_cacerts_path"`
+	IncludeCacertsPassword types.String `tfsdk:"include_cacerts_password"`
+	IncludePemBundlePath   types.String `tfsdk:"include_pem_bundle_path"`
+	Password               types.String `tfsdk:"password"`
+	File                   types.String `tfsdk:"file"`
+	FingerprintsSha256     types.Map    `tfsdk:"fingerprints_sha256"`
+}
+
+// ContentKnown reports whether the certificates of the truststore are known,
+// which they are not during planning while they depend on other resources.
+func (r TruststoreResourceModel) ContentKnown() bool {
+	return !r.Certificates.IsUnknown() && !r.CaChainP7b.IsUnknown() && !r.IncludeCacertsPath.IsUnknown() &&
+		!r.IncludeCacertsPassword.IsUnknown() && !r.IncludePemBundlePath.IsUnknown()
+}
+
+// Entries returns the trusted certificate entries of the truststore: the
+// configured certificates merged with those of the PKCS#7 bundle and of the
+// included files, sorted by alias.
+func (r TruststoreResourceModel) Entries(ctx context.Context) ([]KeystoreEntry, diag.Diagnostics) {
+	configured, diags := r.configuredEntries(ctx)
+	sources := [][]KeystoreEntry{configured}
+
+	if !r.CaChainP7b.IsNull() {
+		certs, err := ParseCertificatesPKCS7(r.CaChainP7b.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("ca_chain_p7b"), "Invalid PKCS#7 certificate chain",
+				fmt.Sprintf("The CA chain could not be parsed.\nError: %s", err))
+		}
+		sources = append(sources, TrustedCertificateEntries(certs))
+	}
+
+	if !r.IncludeCacertsPath.IsNull() {
+		password := DefaultCacertsPassword
+		if !r.IncludeCacertsPassword.IsNull() {
+			password = r.IncludeCacertsPassword.ValueString()
+		}
+		entries, err := ReadCacerts(r.IncludeCacertsPath.ValueString(), password)
+		if err != nil {
+			addKeystoreError(&diags, "read", err, keystoreErrorPaths{
+				ErrIncorrectPassword:    path.Root("include_cacerts_password"),
+				ErrCorruptKeystore:      path.Root("include_cacerts_path"),
+				ErrUnsupportedAlgorithm: path.Root("include_cacerts_path"),
+			})
+		}
+		sources = append(sources, entries)
+	}
+
+	if !r.IncludePemBundlePath.IsNull() {
+		entries, err := ReadPEMBundle(r.IncludePemBundlePath.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("include_pem_bundle_path"), "Invalid PEM bundle", err.Error())
+		}
+		sources = append(sources, entries)
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+	return MergeTrustedCertificates(sources...), diags
+}
+
+// configuredEntries parses the certificates attribute.
+func (r TruststoreResourceModel) configuredEntries(ctx context.Context) ([]KeystoreEntry, diag.Diagnostics) {
+	return ParseTrustedCertificates(ctx, r.Certificates, path.Root("certificates"))
+}
+
+// ParseTrustedCertificates parses a map attribute of PEM encoded
+// certificates by alias, each holding exactly one certificate, as trusted
+// certificate entries sorted by alias. Invalid certificates are reported
+// on their key of the attribute at p.
+func ParseTrustedCertificates(ctx context.Context, certificatesMap types.Map, p path.Path) ([]KeystoreEntry, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var certificates map[string]string
+	diags.Append(certificatesMap.ElementsAs(ctx, &certificates, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	entries := make([]KeystoreEntry, 0, len(certificates))
+	for alias, certificatePEM := range certificates {
+		certs, err := ParseCertificatesPEM(certificatePEM)
+		if err == nil && len(certs) != 1 {
+			err = fmt.Errorf("expected exactly one PEM encoded certificate, got %d", len(certs))
+		}
+		if err != nil {
+			diags.AddAttributeError(p.AtMapKey(alias), "Invalid certificate",
+				fmt.Sprintf("The certificate for alias %q could not be parsed.\nError: %s", alias, err))
+			continue
+		}
+		entries = append(entries, KeystoreEntry{
+			Alias:        alias,
+			Type:         EntryTypeTrustedCertificate,
+			Certificates: certs,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Alias < entries[j].Alias })
+	return entries, diags
+}
+
+// Fingerprints returns the fingerprints_sha256 of entries.
+func Fingerprints(entries []KeystoreEntry) types.Map {
+	fingerprints := make(map[string]attr.Value, len(entries))
+	for _, entry := range entries {
+		fingerprints[entry.Alias] = types.StringValue(SHA256Hex(entry.Certificates[0].Raw))
+	}
+	return types.MapValueMust(types.StringType, fingerprints)
+}
+
+// Encode builds the truststore described by the model and sets file and
+// fingerprints_sha256. When the password and certificates are those of
+// prior, the prior file is kept, as planned: encoding again would pick a new
+// MAC salt and change the file.
+func (r *TruststoreResourceModel) Encode(ctx context.Context, prior *TruststoreResourceModel) diag.Diagnostics {
+	entries, diags := r.Entries(ctx)
+	if diags.HasError() {
+		return diags
+	}
+	if prior != nil && r.Password.Equal(prior.Password) && Fingerprints(entries).Equal(prior.FingerprintsSha256) {
+		r.File = prior.File
+		r.FingerprintsSha256 = prior.FingerprintsSha256
+		return diags
+	}
+
+	password := r.Password.ValueString()
+	ctx = keystoreLogContext(ctx, password)
+
+	aliases := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		aliases = append(aliases, entry.Alias)
+	}
+
+	start := time.Now()
+	data, err := encodePKCS12(entries, password, PKCS12Protection{})
+	logKeystoreOperation(ctx, "encode", StoreTypePKCS12, aliases, start, err)
+	if err != nil {
+		addKeystoreError(&diags, "encode", fmt.Errorf("error encoding truststore\nError: %w", err), keystoreErrorPaths{})
+		return diags
+	}
+	r.File = types.StringValue(base64.StdEncoding.EncodeToString(data))
+	r.FingerprintsSha256 = Fingerprints(entries)
+	return diags
+}
+
+func (r *TruststoreResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_truststore"
+}
+
+func (r *TruststoreResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		Description: `
+        Builds a PKCS12 truststore holding a trusted certificate entry for each of the given certificates.
+        The certificates can be merged with those of a JDK cacerts file and of a system CA bundle, which are read when planning, so that changes to them are applied.
+        The truststore is encoded by the provider itself and does not require the keytool utility.
+        Without a password the truststore has neither an integrity MAC nor encrypted certificates, which Java 18 and later load without a password.
+        `,
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Generated UUID for the truststore",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"certificates": schema.MapAttribute{
+				Description: "PEM encoded certificates to trust, by alias. Each value holds exactly one certificate. " +
+					"At least one of certificates, ca_chain_p7b, include_cacerts_path and include_pem_bundle_path must be set",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.SizeAtLeast(1),
+				},
+			},
+			"ca_chain_p7b": schema.StringAttribute{
+				Description: "PKCS#7 bundle of certificates to trust, such as a .p7b file, in DER or BER as enterprise CAs write it, base64 encoded or in PEM format. " +
+					"Each is named as the certificates of include_pem_bundle_path are. Certificates already in certificates are left out",
+				Optional: true,
+			},
+			"include_cacerts_path": schema.StringAttribute{
+				Description: "Path of a JKS, JCEKS or PKCS12 truststore, such as the lib/security/cacerts file of a JDK, whose trusted certificates are included. " +
+					"They keep their aliases, unless one is taken by certificates. Certificates already in certificates or ca_chain_p7b are left out",
+				Optional: true,
+			},
+			"include_cacerts_password": schema.StringAttribute{
+				Description: "Password of the include_cacerts_path truststore. Defaults to changeit, the password of the JDK cacerts files",
+				Optional:    true,
+				Sensitive:   true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("include_cacerts_path")),
+				},
+			},
+			"include_pem_bundle_path": schema.StringAttribute{
+				Description: "Path of a bundle of PEM encoded CA certificates, such as /etc/ssl/certs/ca-certificates.crt, whose certificates are included. " +
+					"Each is named by its lower case common name and the first 8 hex digits of its SHA-256 fingerprint, such as \"isrg root x1 [96bcec06]\". " +
+					"Certificates already in certificates, ca_chain_p7b or include_cacerts_path are left out",
+				Optional: true,
+			},
+			"password": schema.StringAttribute{
+				Description: "Password protecting the integrity of the truststore, at least 6 characters long. " +
+					"When unset, the truststore is written without a password, as Java 18 and later write password-less truststores",
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(MinPasswordLength),
+				},
+			},
+			"file": schema.StringAttribute{
+				Description: "Base64 encoded truststore file",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					keepTruststoreUnlessContentChanges{},
+				},
+			},
+			"fingerprints_sha256": schema.MapAttribute{
+				Description: "Hex encoded SHA-256 fingerprints of the certificates in the truststore, by alias",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					truststoreFingerprintsFromSources{},
+				},
+			},
+		},
+	}
+}
+
+func (r *TruststoreResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.AtLeastOneOf(
+			path.MatchRoot("certificates"),
+			path.MatchRoot("ca_chain_p7b"),
+			path.MatchRoot("include_cacerts_path"),
+			path.MatchRoot("include_pem_bundle_path"),
+		),
+	}
+}
+
+func (r *TruststoreResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data TruststoreResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(data.Encode(ctx, nil)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.New().String())
+
+	tflog.Trace(ctx, "created a truststore")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TruststoreResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data TruststoreResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TruststoreResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, prior TruststoreResourceModel
+
+	// Read Terraform plan and prior state data into the models
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(data.Encode(ctx, &prior)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TruststoreResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data TruststoreResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+const TestTruststoreFullName = "jks_truststore.test"
+
+func TestAccTruststoreResource(t *testing.T) {
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+	intermediate := testSelfSignedCertificatePEM(t, "Intermediate CA")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTruststoreConfig(map[string]string{"root": root}, `password = "MyPassword12345"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckResourceAttrLengthGreater(TestTruststoreFullName, "id", 0),
+					testCheckTruststore("MyPassword12345", "root"),
+				),
+			},
+			// Dropping the password rewrites the truststore without one.
+			{
+				Config: testAccTruststoreConfig(map[string]string{"root": root, "intermediate": intermediate}, ""),
+				Check:  testCheckTruststore("", "intermediate", "root"),
+			},
+			{
+				Config:      testAccTruststoreConfig(map[string]string{"root": "not a certificate"}, ""),
+				ExpectError: regexp.MustCompile("Invalid certificate"),
+			},
+		},
+	})
+}
+
+func TestAccTruststoreResourcePKCS7(t *testing.T) {
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+	chain := testTrustedEntry(t, "", "Partner Root CA")
+	p7b, err := EncodeCertificatesPKCS7(chain.Certificates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := fmt.Sprintf("ca_chain_p7b = %q", base64.StdEncoding.EncodeToString(p7b))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTruststoreConfig(map[string]string{"root": root}, extra),
+				Check:  testCheckTruststore("", TrustedCertificateAlias(chain.Certificates[0]), "root"),
+			},
+			{
+				Config:      testAccTruststoreConfig(map[string]string{"root": root}, `ca_chain_p7b = "bm90IGEgYnVuZGxl"`),
+				ExpectError: regexp.MustCompile("Invalid PKCS#7 certificate chain"),
+			},
+		},
+	})
+}
+
+func TestAccTruststoreResourceIncludes(t *testing.T) {
+	internal := testSelfSignedCertificatePEM(t, "Internal Root CA")
+	public := testTrustedEntry(t, "public [jdk]", "Public Root CA")
+	cacerts := testWriteFile(t, "cacerts", testEncodeJKS(t, StoreTypeJKS, []KeystoreEntry{public}, DefaultCacertsPassword))
+	bundle := testWriteFile(t, "ca-certificates.crt", []byte(internal))
+
+	extra := fmt.Sprintf("include_cacerts_path = %q\n  include_pem_bundle_path = %q", cacerts, bundle)
+	added := testTrustedEntry(t, "", "Added Root CA")
+	copied := testWriteFile(t, "copied.crt", []byte(internal+EncodeCertificatesPEM(added.Certificates)))
+	var file string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTruststoreConfig(map[string]string{"internal": internal}, extra),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// The bundled copy of internal is left out.
+					testCheckTruststore("", "internal", "public [jdk]"),
+					resource.TestCheckResourceAttr(TestTruststoreFullName, "fingerprints_sha256.%", "2"),
+				),
+			},
+			// A certificate added to the bundle is picked up when planning.
+			{
+				PreConfig: func() {
+					data := internal + EncodeCertificatesPEM(added.Certificates)
+					if err := os.WriteFile(bundle, []byte(data), 0644); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccTruststoreConfig(map[string]string{"internal": internal}, extra),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckTruststore("", TrustedCertificateAlias(added.Certificates[0]), "internal", "public [jdk]"),
+					resource.TestCheckResourceAttrWith(TestTruststoreFullName, "file", func(value string) error {
+						file = value
+						return nil
+					}),
+				),
+			},
+			// A bundle holding the same certificates keeps the truststore.
+			{
+				Config: testAccTruststoreConfig(map[string]string{"internal": internal}, fmt.Sprintf("include_cacerts_path = %q\n  include_pem_bundle_path = %q", cacerts, copied)),
+				Check: resource.TestCheckResourceAttrWith(TestTruststoreFullName, "file", func(value string) error {
+					if value != file {
+						return fmt.Errorf("expected the truststore to be kept")
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func testAccTruststoreConfig(certificates map[string]string, extra string) string {
+	config := "resource \"jks_truststore\" \"test\" {\n  certificates = {\n"
+	for alias, certificate := range certificates {
+		config += fmt.Sprintf("    %q = %q\n", alias, certificate)
+	}
+	return config + fmt.Sprintf("  }\n  %s\n}\n", extra)
+}
+
+// testCheckTruststore checks that the truststore decodes with password and
+// holds trusted certificate entries under aliases.
+func testCheckTruststore(password string, aliases ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[TestTruststoreFullName]
+		if !ok {
+			return fmt.Errorf("not found: %s", TestTruststoreFullName)
+		}
+		ks, err := InspectKeystore(context.Background(), rs.Primary.Attributes["file"], StoreTypePKCS12, password)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(ks.Aliases()) != fmt.Sprint(aliases) {
+			return fmt.Errorf("expected aliases %v, got %v", aliases, ks.Aliases())
+		}
+		for _, entry := range ks.Entries {
+			if entry.Type != EntryTypeTrustedCertificate {
+				return fmt.Errorf("expected %s to be a trusted certificate, got %s", entry.Alias, entry.Type)
+			}
+		}
+		return nil
+	}
+}
+
+func TestTruststoreResourceModelEncode(t *testing.T) {
+	ctx := context.Background()
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+
+	for _, password := range []string{"", "MyPassword12345"} {
+		data := TruststoreResourceModel{
+			Certificates: types.MapValueMust(types.StringType, map[string]attr.Value{
+				"root": types.StringValue(root),
+			}),
+			IncludeCacertsPath:     types.StringNull(),
+			IncludeCacertsPassword: types.StringNull(),
+			IncludePemBundlePath:   types.StringNull(),
+			Password:               types.StringValue(password),
+		}
+		if password == "" {
+			data.Password = types.StringNull()
+		}
+
+		if diags := data.Encode(ctx, nil); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		raw, err := base64.StdEncoding.DecodeString(data.File.ValueString())
+		if err != nil {
+			t.Fatal(err)
+		}
+		protection, err := inspectPKCS12Protection(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (protection.MacAlgorithm == "") != (password == "") {
+			t.Errorf("expected a MAC only with a password, got %+v", protection)
+		}
+		if _, err := DecodeKeystore(raw, StoreTypePKCS12, password); err != nil {
+			t.Error(err)
+		}
+		if fingerprints := data.FingerprintsSha256.Elements(); len(fingerprints) != 1 || fingerprints["root"] == nil {
+			t.Errorf("expected the fingerprint of root, got %v", data.FingerprintsSha256)
+		}
+
+		// Encoding the same content again keeps the prior file.
+		prior := data
+		if diags := data.Encode(ctx, &prior); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if !data.File.Equal(prior.File) {
+			t.Error("expected the prior file to be kept")
+		}
+		data.Password = types.StringValue("AnotherPassword")
+		if diags := data.Encode(ctx, &prior); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if data.File.Equal(prior.File) {
+			t.Error("expected a new password to encode the truststore again")
+		}
+	}
+}
+
+func TestTruststoreResourceModelEntriesInvalid(t *testing.T) {
+	root := testSelfSignedCertificatePEM(t, "Root CA")
+	data := TruststoreResourceModel{
+		Certificates: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"chain": types.StringValue(root + root),
+		}),
+		IncludeCacertsPath:     types.StringNull(),
+		IncludeCacertsPassword: types.StringNull(),
+		IncludePemBundlePath:   types.StringNull(),
+		Password:               types.StringNull(),
+	}
+
+	_, diags := data.Entries(context.Background())
+	if !diags.HasError() {
+		t.Fatal("expected a chain to be rejected")
+	}
+	if d, ok := diags[0].(diag.DiagnosticWithPath); !ok || !d.Path().Equal(path.Root("certificates").AtMapKey("chain")) {
+		t.Errorf("expected the error on the chain alias, got %v", diags)
+	}
+}
+
+func TestTruststoreResourceModelEntriesPKCS7(t *testing.T) {
+	root := testTrustedEntry(t, "root", "Root CA")
+	partner := testTrustedEntry(t, "", "Partner Root CA")
+	p7b, err := EncodeCertificatesPKCS7(append(root.Certificates, partner.Certificates...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := TruststoreResourceModel{
+		Certificates: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"root": types.StringValue(EncodeCertificatesPEM(root.Certificates)),
+		}),
+		CaChainP7b:             types.StringValue(base64.StdEncoding.EncodeToString(p7b)),
+		IncludeCacertsPath:     types.StringNull(),
+		IncludeCacertsPassword: types.StringNull(),
+		IncludePemBundlePath:   types.StringNull(),
+		Password:               types.StringNull(),
+	}
+
+	entries, diags := data.Entries(context.Background())
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	// The copy of root in the bundle is left out.
+	aliases := []string{"root", TrustedCertificateAlias(partner.Certificates[0])}
+	if len(entries) != 2 || entries[0].Alias != aliases[1] || entries[1].Alias != aliases[0] {
+		t.Errorf("expected aliases %v, got %v", aliases, entries)
+	}
+
+	data.CaChainP7b = types.StringValue("not a bundle")
+	_, diags = data.Entries(context.Background())
+	if !diags.HasError() {
+		t.Fatal("expected an invalid bundle to be rejected")
+	}
+	if d, ok := diags[0].(diag.DiagnosticWithPath); !ok || !d.Path().Equal(path.Root("ca_chain_p7b")) {
+		t.Errorf("expected the error on ca_chain_p7b, got %v", diags)
+	}
+}
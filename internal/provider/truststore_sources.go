@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultCacertsPassword is the password of the cacerts files of the JDK.
+// Since Java 18 they are password-less and any password reads them.
+const DefaultCacertsPassword = "changeit"
+
+// ReadCacerts reads the trusted certificate entries of a JDK cacerts file,
+// or of any other JKS, JCEKS or PKCS12 truststore. Private key entries are
+// left out. The store type is detected, as the cacerts files of the JDK are
+// JKS up to Java 17 and PKCS12 since Java 18.
+func ReadCacerts(path, password string) ([]KeystoreEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s\nError: %s", path, err)
+	}
+	ks, err := DecodeKeystore(data, "", password)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s\nError: %w", path, err)
+	}
+	var entries []KeystoreEntry
+	for _, entry := range ks.Entries {
+		if entry.Type == EntryTypeTrustedCertificate {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// ReadPEMBundle reads a bundle of PEM encoded CA certificates, such as
+// /etc/ssl/certs/ca-certificates.crt, as trusted certificate entries named
+// by TrustedCertificateAlias.
+func ReadPEMBundle(path string) ([]KeystoreEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s\nError: %s", path, err)
+	}
+	certs, err := ParseCertificatesPEM(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s\nError: %s", path, err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found in %s", path)
+	}
+	return TrustedCertificateEntries(certs), nil
+}
+
+// TrustedCertificateEntries returns a trusted certificate entry for each of
+// certs, named by TrustedCertificateAlias.
+func TrustedCertificateEntries(certs []*x509.Certificate) []KeystoreEntry {
+	entries := make([]KeystoreEntry, 0, len(certs))
+	for _, cert := range certs {
+		entries = append(entries, KeystoreEntry{
+			Alias:        TrustedCertificateAlias(cert),
+			Type:         EntryTypeTrustedCertificate,
+			Certificates: []*x509.Certificate{cert},
+		})
+	}
+	return entries
+}
+
+// TrustedCertificateAlias names a certificate that comes without an alias
+// by its common name, or its whole subject, followed by the start of its
+// SHA-256 fingerprint, in the style of the "name [jdk]" aliases of the JDK
+// cacerts. The name only depends on the certificate, so it stays the same
+// when the bundle it comes from changes. It is lower case, because Java
+// looks up PKCS12 aliases ignoring case.
+func TrustedCertificateAlias(cert *x509.Certificate) string {
+	name := cert.Subject.CommonName
+	if name == "" {
+		name = cert.Subject.String()
+	}
+	return fmt.Sprintf("%s [%s]", strings.ToLower(name), SHA256Hex(cert.Raw)[:8])
+}
+
+// MergeTrustedCertificates merges sources of trusted certificate entries in
+// order of precedence. A certificate already merged from an earlier source
+// is dropped, and one whose alias is already taken is renamed by
+// TrustedCertificateAlias. The result is sorted by alias.
+func MergeTrustedCertificates(sources ...[]KeystoreEntry) []KeystoreEntry {
+	var merged []KeystoreEntry
+	fingerprints := map[string]bool{}
+	aliases := map[string]bool{}
+
+	for i, source := range sources {
+		for _, entry := range source {
+			fingerprint := SHA256Hex(entry.Certificates[0].Raw)
+			// Certificates given twice in the first source are kept under
+			// both aliases, as configured.
+			if i > 0 && fingerprints[fingerprint] {
+				continue
+			}
+			if aliases[strings.ToLower(entry.Alias)] {
+				entry.Alias = TrustedCertificateAlias(entry.Certificates[0])
+			}
+			fingerprints[fingerprint] = true
+			aliases[strings.ToLower(entry.Alias)] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Alias < merged[j].Alias })
+	return merged
+}
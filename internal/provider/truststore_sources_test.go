@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testTrustedEntry returns a trusted certificate entry for a new self-signed
+// certificate.
+func testTrustedEntry(t *testing.T, alias, commonName string) KeystoreEntry {
+	t.Helper()
+
+	certs, err := ParseCertificatesPEM(testSelfSignedCertificatePEM(t, commonName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return KeystoreEntry{Alias: alias, Type: EntryTypeTrustedCertificate, Certificates: certs}
+}
+
+// testWriteFile writes data to a file in a temporary directory.
+func testWriteFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestReadCacerts(t *testing.T) {
+	server := testFixtureEntry(t, "testdata/openssl-modern.p12", "changeit", "server")
+	root := testTrustedEntry(t, "root [jdk]", "Root CA")
+	entries := []KeystoreEntry{*server, root}
+
+	for _, storeType := range []string{StoreTypeJKS, StoreTypeJCEKS, StoreTypePKCS12} {
+		t.Run(storeType, func(t *testing.T) {
+			var data []byte
+			if storeType == StoreTypePKCS12 {
+				var err error
+				if data, err = encodePKCS12(entries, DefaultCacertsPassword, PKCS12Protection{}); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				data = testEncodeJKS(t, storeType, entries, DefaultCacertsPassword)
+			}
+			cacerts := testWriteFile(t, "cacerts", data)
+
+			read, err := ReadCacerts(cacerts, DefaultCacertsPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(read) != 1 || read[0].Alias != "root [jdk]" || !read[0].Certificates[0].Equal(root.Certificates[0]) {
+				t.Errorf("expected only the trusted certificate, got %v", read)
+			}
+
+			if _, err := ReadCacerts(cacerts, "wrong-password"); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("expected ErrIncorrectPassword, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadCacertsPasswordless(t *testing.T) {
+	root := testTrustedEntry(t, "root [jdk]", "Root CA")
+	data, err := encodePKCS12([]KeystoreEntry{root}, "", PKCS12Protection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cacerts of Java 18 and later are read with the default password.
+	read, err := ReadCacerts(testWriteFile(t, "cacerts", data), DefaultCacertsPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 1 {
+		t.Errorf("expected 1 entry, got %d", len(read))
+	}
+}
+
+func TestReadPEMBundle(t *testing.T) {
+	bundle := testSelfSignedCertificatePEM(t, "Root CA") + testSelfSignedCertificatePEM(t, "")
+
+	entries, err := ReadPEMBundle(testWriteFile(t, "ca-certificates.crt", []byte(bundle)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Alias != TrustedCertificateAlias(entry.Certificates[0]) {
+			t.Errorf("expected %s to be named by TrustedCertificateAlias", entry.Alias)
+		}
+	}
+	if !strings.HasPrefix(entries[0].Alias, "root ca [") {
+		t.Errorf("expected the first entry to be named after its common name, got %s", entries[0].Alias)
+	}
+
+	if _, err := ReadPEMBundle(testWriteFile(t, "empty.crt", nil)); err == nil {
+		t.Error("expected an empty bundle to be rejected")
+	}
+	if _, err := ReadPEMBundle(filepath.Join(t.TempDir(), "missing.crt")); err == nil {
+		t.Error("expected a missing bundle to be rejected")
+	}
+}
+
+func TestTrustedCertificateAlias(t *testing.T) {
+	entry := testTrustedEntry(t, "", "Example Root CA")
+	cert := entry.Certificates[0]
+
+	alias := TrustedCertificateAlias(cert)
+	if alias != "example root ca ["+SHA256Hex(cert.Raw)[:8]+"]" {
+		t.Errorf("unexpected alias %s", alias)
+	}
+	if TrustedCertificateAlias(cert) != alias {
+		t.Error("expected the alias to be deterministic")
+	}
+
+	unnamed := testTrustedEntry(t, "", "").Certificates[0]
+	if alias := TrustedCertificateAlias(unnamed); !strings.HasSuffix(alias, "["+SHA256Hex(unnamed.Raw)[:8]+"]") {
+		t.Errorf("unexpected alias %s", alias)
+	}
+}
+
+func TestMergeTrustedCertificates(t *testing.T) {
+	internal := testTrustedEntry(t, "internal", "Internal Root CA")
+	public := testTrustedEntry(t, "public [jdk]", "Public Root CA")
+	other := testTrustedEntry(t, "Internal", "Other Root CA")
+
+	bundled := func(e KeystoreEntry) KeystoreEntry {
+		e.Alias = TrustedCertificateAlias(e.Certificates[0])
+		return e
+	}
+
+	merged := MergeTrustedCertificates(
+		[]KeystoreEntry{internal, {Alias: "internal copy", Type: EntryTypeTrustedCertificate, Certificates: internal.Certificates}},
+		[]KeystoreEntry{public, other},
+		[]KeystoreEntry{bundled(internal), bundled(public), bundled(public)},
+	)
+
+	expected := map[string]*x509.Certificate{
+		"internal":      internal.Certificates[0],
+		"internal copy": internal.Certificates[0],
+		"public [jdk]":  public.Certificates[0],
+		// The alias of other is taken by internal, ignoring case.
+		TrustedCertificateAlias(other.Certificates[0]): other.Certificates[0],
+	}
+	if len(merged) != len(expected) {
+		t.Fatalf("expected %d entries, got %v", len(expected), merged)
+	}
+	for i, entry := range merged {
+		cert, ok := expected[entry.Alias]
+		if !ok || !entry.Certificates[0].Equal(cert) {
+			t.Errorf("unexpected entry %s", entry.Alias)
+		}
+		if i > 0 && merged[i-1].Alias > entry.Alias {
+			t.Error("expected the entries to be sorted by alias")
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &VerifyChainFunction{}
+
+func NewVerifyChainFunction() function.Function {
+	return &VerifyChainFunction{}
+}
+
+// VerifyChainFunction defines the function implementation.
+type VerifyChainFunction struct {
+}
+
+func (f *VerifyChainFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "verify_chain"
+}
+
+func (f *VerifyChainFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Checks the certificate chains of a keystore against a truststore",
+		Description: "Verifies the certificate chain of every private key entry of a base64 encoded PKCS12, JKS or JCEKS keystore up to one of the trusted certificates of a base64 encoded PKCS12, JKS or JCEKS truststore, " +
+			"including the validity period of each certificate at the given time. " +
+			"Pass plantimestamp() as the time to check against the time of the plan, so that the result does not change between plan and apply. " +
+			"Returns an object whose valid attribute is the result and whose message attribute explains it, for use in the condition and error_message of a precondition or check block.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "keystore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS keystore",
+			},
+			function.StringParameter{
+				Name:        "password",
+				Description: "Password for the keystore and its keys",
+			},
+			function.StringParameter{
+				Name:        "truststore",
+				Description: "Base64 encoded PKCS12, JKS or JCEKS truststore",
+			},
+			function.StringParameter{
+				Name:        "truststore_password",
+				Description: "Password for the truststore",
+			},
+			function.StringParameter{
+				Name:        "time",
+				Description: "Time at which the validity periods are checked, in RFC 3339 format, such as plantimestamp()",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: verificationAttrTypes,
+		},
+	}
+}
+
+func (f *VerifyChainFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var keystore, password, truststore, truststorePassword, at string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &keystore, &password, &truststore, &truststorePassword, &at))
+
+	if resp.Error != nil {
+		return
+	}
+
+	now, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(4, fmt.Sprintf("time must be in RFC 3339 format, such as 2006-01-02T15:04:05Z: %s", err))
+		return
+	}
+
+	ks, funcErr := inspectKeystoreArguments(ctx, keystore, "", password)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	ts, err := InspectKeystore(ctx, truststore, "", truststorePassword)
+	if errors.Is(err, ErrIncorrectPassword) {
+		resp.Error = function.NewArgumentFuncError(3, err.Error())
+		return
+	}
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(2, err.Error())
+		return
+	}
+
+	result := NewVerificationModel(VerifyChain(ks, ts, now), "the certificate chains of all private key entries are trusted")
+	value, diags := types.ObjectValueFrom(ctx, verificationAttrTypes, result)
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, value))
+}
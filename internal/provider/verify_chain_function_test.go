@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccVerifyChainFunction(t *testing.T) {
+	keystore := testFixtureBase64(t, "testdata/openssl-modern.p12")
+	truststore := testFixtureBase64(t, "testdata/go-pkcs12-truststore.p12")
+	ts, err := InspectKeystore(context.Background(), truststore, StoreTypePKCS12, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jksTruststore := base64.StdEncoding.EncodeToString(testEncodeJKS(t, StoreTypeJKS, ts.Entries, "changeit"))
+	config := func(truststore, at string) string {
+		return fmt.Sprintf(`
+output "test" {
+  value = provider::jks::verify_chain(%q, "changeit", %q, "changeit", %q)
+}
+`, keystore, truststore, at)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(truststore, "2030-01-01T00:00:00Z"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"valid": knownvalue.Bool(true),
+					})),
+				},
+			},
+			{
+				Config: config(keystore, "2030-01-01T00:00:00Z"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+						"valid":   knownvalue.Bool(false),
+						"message": knownvalue.StringExact("truststore contains no trusted certificates"),
+					})),
+				},
+			},
+			// The store type of the truststore is detected.
+			{
+				Config: config(jksTruststore, "2030-01-01T00:00:00Z"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"valid": knownvalue.Bool(true),
+					})),
+				},
+			},
+			// The fixtures were issued in 2026.
+			{
+				Config: config(truststore, "2020-01-01T00:00:00Z"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectPartial(map[string]knownvalue.Check{
+						"valid": knownvalue.Bool(false),
+					})),
+				},
+			},
+			{
+				Config:      config(truststore, "yesterday"),
+				ExpectError: regexp.MustCompile("time must be in RFC 3339 format"),
+			},
+		},
+	})
+}
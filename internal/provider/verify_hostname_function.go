@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &VerifyHostnameFunction{}
+
+func NewVerifyHostnameFunction() function.Function {
+	return &VerifyHostnameFunction{}
+}
+
+// VerifyHostnameFunction defines the function implementation.
+type VerifyHostnameFunction struct {
+}
+
+func (f *VerifyHostnameFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "verify_hostname"
+}
+
+func (f *VerifyHostnameFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Checks that a certificate is valid for a hostname",
+		Description: "Checks the subject alternative names of the first certificate in a PEM string against a hostname or IP address, with wildcard matching as TLS clients do. " +
+			"Returns an object whose valid attribute is the result and whose message attribute explains it, for use in the condition and error_message of a precondition or check block.",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "certificate_pem",
+				Description: "PEM encoded certificate, such as the result of provider::jks::certificate_pem",
+			},
+			function.StringParameter{
+				Name:        "hostname",
+				Description: "Hostname or IP address to check",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: verificationAttrTypes,
+		},
+	}
+}
+
+func (f *VerifyHostnameFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var certificatePEM, hostname string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &certificatePEM, &hostname))
+
+	if resp.Error != nil {
+		return
+	}
+
+	certs, err := ParseCertificatesPEM(certificatePEM)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("error parsing certificate: %s", err))
+		return
+	}
+	if len(certs) == 0 {
+		resp.Error = function.NewArgumentFuncError(0, "no certificate found")
+		return
+	}
+
+	result := NewVerificationModel(VerifyHostname(certs[0], hostname), fmt.Sprintf("certificate %s is valid for %s", certs[0].Subject, hostname))
+	value, diags := types.ObjectValueFrom(ctx, verificationAttrTypes, result)
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, value))
+}
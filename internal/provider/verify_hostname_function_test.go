@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccVerifyHostnameFunction(t *testing.T) {
+	certificatePEM := testSelfSignedCertificatePEM(t, "api.example.com")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+output "test" {
+  value = provider::jks::verify_hostname(%q, "api.example.com")
+}
+`, certificatePEM),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+						"valid":   knownvalue.Bool(false),
+						"message": knownvalue.StringRegexp(regexp.MustCompile("use SANs instead")),
+					})),
+				},
+			},
+			{
+				Config: `
+output "test" {
+  value = provider::jks::verify_hostname("not a certificate", "api.example.com")
+}
+`,
+				ExpectError: regexp.MustCompile("no certificate found"),
+			},
+		},
+	})
+}